@@ -5,12 +5,19 @@ import (
 	"os"
 	"path"
 	"strings"
+	"versioner/internal/config"
 	"versioner/internal/detect"
+	"versioner/internal/fsutil"
 
 	"github.com/pkg/errors"
 )
 
-const commentStr = "[//]: # entry"
+const (
+	FileName   = "CHANGELOG.md"
+	commentStr = "[//]: # entry"
+)
+
+var ErrUnknownFormat = errors.New("changelog is not in versioner format, run `versioner changelog import` to convert it")
 
 type Changelog struct {
 	Title   string
@@ -18,18 +25,28 @@ type Changelog struct {
 	Path    string
 }
 
-func Parse(wd string) (Changelog, error) {
+// File is the changelog path of the config, relative to the project.
+func File(conf config.Configuration) string {
+	if len(conf.Changelog) > 0 {
+		return conf.Changelog
+	}
+
+	return FileName
+}
+
+// Parse reads the changelog at file relative to wd, creating it when missing.
+func Parse(wd, file string) (Changelog, error) {
 	p, err := detect.Run(wd)
 	if err != nil {
 		return Changelog{}, errors.Wrap(err, "could not get changelog")
 	}
 
-	filePath := path.Join(wd, "CHANGELOG.md")
+	filePath := path.Join(wd, file)
 	title := fmt.Sprintf("# %s\n", p.Name)
 
 	_, err = os.Stat(filePath)
 	if errors.Is(err, os.ErrNotExist) {
-		if err = os.WriteFile(filePath, []byte(title), os.ModePerm); err != nil {
+		if err = fsutil.WriteFile(filePath, []byte(title)); err != nil {
 			return Changelog{}, errors.Wrap(err, "could not get changelog")
 		}
 	}
@@ -39,33 +56,64 @@ func Parse(wd string) (Changelog, error) {
 		return Changelog{}, errors.Wrap(err, "could not get changelog")
 	}
 
+	c, err := ParseMarkdown(p.Name, string(b))
+	if err != nil {
+		return Changelog{}, err
+	}
+
+	c.Path = filePath
+
+	return c, nil
+}
+
+// ParseMarkdown parses changelog content that is not read from the working
+// directory, such as CHANGELOG.md in an earlier commit.
+func ParseMarkdown(name, content string) (Changelog, error) {
 	c := Changelog{
-		Title: p.Name,
-		Path:  filePath,
+		Title: name,
 	}
 
-	content := string(b)
-	content = strings.ReplaceAll(content, title, "")
+	content = strings.ReplaceAll(content, fmt.Sprintf("# %s\n", name), "")
 	entryStrs := removeEmptyStrings(strings.Split(content, commentStr))
 
 	for _, eStr := range entryStrs {
+		if len(strings.TrimSpace(eStr)) == 0 {
+			continue
+		}
+
 		e, err := parseEntry(eStr)
 		if err != nil {
 			return Changelog{}, err
 		}
 
+		if len(e.Version) == 0 && len(e.Sections) == 0 {
+			continue
+		}
+
 		c.Entries = append(c.Entries, e)
 	}
 
 	return c, nil
 }
 
+func (c Changelog) Entry(version string) (Entry, bool) {
+	for _, e := range c.Entries {
+		if e.Version == version {
+			return e, true
+		}
+	}
+
+	return Entry{}, false
+}
+
 func (c Changelog) Markdown() string {
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("# %s\n", c.Title))
 
 	for _, e := range c.Entries {
+		sb.WriteString("\n")
+		sb.WriteString(commentStr)
 		sb.WriteString("\n")
 		sb.WriteString(e.Markdown())
 	}
@@ -76,7 +124,7 @@ func (c Changelog) Markdown() string {
 func (c Changelog) Save() error {
 	content := c.Markdown()
 
-	return errors.Wrap(os.WriteFile(c.Path, []byte(content), os.ModePerm), "could not save Changelog.md")
+	return errors.Wrap(fsutil.WriteFile(c.Path, []byte(content)), "could not save Changelog.md")
 }
 
 func (c *Changelog) Add(e Entry) {
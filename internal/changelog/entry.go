@@ -9,6 +9,8 @@ import (
 	"github.com/pkg/errors"
 )
 
+const breakingTitle = "Breaking changes"
+
 type Section struct {
 	Title   string
 	Content []string
@@ -25,61 +27,57 @@ func NewEntry(curr semver.Version, cc changeset.Changesets) (Entry, error) {
 		return Entry{}, errors.Wrap(err, "could not create a new entry")
 	}
 
-	newVer := bumpVersion(curr, next)
+	return EntryForVersion(bumpVersion(curr, next), cc)
+}
 
+// EntryForVersion creates an entry for an already known version instead of
+// calculating the next one from the changesets.
+func EntryForVersion(ver semver.Version, cc changeset.Changesets) (Entry, error) {
 	ss := []Section{}
 
 	for _, c := range cc {
-		ct, err := c.ConventionalType()
+		title, err := SectionTitle(c)
 		if err != nil {
 			return Entry{}, errors.Wrap(err, "could not create a new entry")
 		}
 
-		title := ct.Title
-
-		if c.Breaking {
-			title = "Breaking changes"
-		}
-
-		s := getOrCreateSection(title, ss)
-		s.Content = append(s.Content, c.Summary)
-
-		ss = append(ss, s)
+		ss = addToSection(title, c.Summary, ss)
 	}
 
 	e := Entry{
-		Version:  newVer.String(),
+		Version:  ver.String(),
 		Sections: ss,
 	}
 
 	return e, nil
 }
 
-func (e Entry) Markdown() string {
-	var sb strings.Builder
+// SectionTitle is the title of the section the changeset is listed under.
+func SectionTitle(c changeset.Changeset) (string, error) {
+	ct, err := c.ConventionalType()
+	if err != nil {
+		return "", err
+	}
 
-	sb.WriteString(fmt.Sprintf("## %s\n", e.Version))
+	if c.Breaking {
+		return breakingTitle, nil
+	}
 
-	group := e.groupSections()
+	return ct.Title, nil
+}
 
-	if cc, ok := group["Breaking changes"]; ok {
-		sb.WriteString("\n")
-		sb.WriteString("### Breaking changes")
+func (e Entry) Markdown() string {
+	var sb strings.Builder
 
-		for _, c := range cc {
-			sb.WriteString("\n")
-			sb.WriteString(c)
-			sb.WriteString("\n")
-		}
+	sb.WriteString(fmt.Sprintf("## %s\n", e.Version))
 
-		delete(group, "Breaking changes")
-	}
+	titles, group := e.groupSections()
 
-	for k, cc := range group {
+	for _, t := range titles {
 		sb.WriteString("\n")
-		sb.WriteString(fmt.Sprintf("### %s\n", k))
+		sb.WriteString(fmt.Sprintf("### %s\n", t))
 
-		for _, c := range cc {
+		for _, c := range group[t] {
 			sb.WriteString("\n")
 			sb.WriteString(c)
 			sb.WriteString("\n")
@@ -89,7 +87,10 @@ func (e Entry) Markdown() string {
 	return sb.String()
 }
 
-func (e Entry) groupSections() map[string][]string {
+// groupSections merges sections sharing a title, keeping the order in which
+// they first appear with breaking changes always on top.
+func (e Entry) groupSections() ([]string, map[string][]string) {
+	titles := []string{}
 	m := map[string][]string{}
 
 	for _, s := range e.Sections {
@@ -98,42 +99,48 @@ func (e Entry) groupSections() map[string][]string {
 			continue
 		}
 
-		m[s.Title] = s.Content
+		if s.Title == breakingTitle {
+			titles = append([]string{s.Title}, titles...)
+		} else {
+			titles = append(titles, s.Title)
+		}
+
+		m[s.Title] = append([]string{}, s.Content...)
 	}
 
-	return m
+	return titles, m
 }
 
 func parseEntry(str string) (Entry, error) {
 	e := Entry{}
 
-	sCount := 0
 	sections := []Section{}
 
 	ss := removeEmptyStrings(strings.Split(str, "\n"))
 	for _, s := range ss {
-		if strings.Contains(s, "## ") {
+		if strings.HasPrefix(s, "## ") {
+			if len(e.Version) > 0 {
+				return Entry{}, ErrUnknownFormat
+			}
+
 			ver, err := semver.NewVersion(strings.Replace(s, "## ", "", 1))
 			if err != nil {
 				return Entry{}, err
 			}
 
 			e.Version = ver.String()
+			continue
 		}
 
-		if strings.Contains(s, "### ") {
-			if sCount > 0 {
-				sCount++
-			}
-
+		if strings.HasPrefix(s, "### ") {
 			title := strings.Replace(s, "### ", "", 1)
 			sections = append(sections, Section{Title: title})
+			continue
 		}
 
 		if len(sections) > 0 {
-			sections[sCount].Content = append(sections[sCount].Content, s)
+			sections[len(sections)-1].Content = append(sections[len(sections)-1].Content, s)
 		}
-
 	}
 
 	e.Sections = sections
@@ -156,14 +163,15 @@ func bumpVersion(ver semver.Version, bump string) semver.Version {
 	return newVer
 }
 
-func getOrCreateSection(title string, ss []Section) Section {
-	for _, s := range ss {
+func addToSection(title, content string, ss []Section) []Section {
+	for i, s := range ss {
 		if s.Title == title {
-			return s
+			ss[i].Content = append(ss[i].Content, content)
+			return ss
 		}
 	}
 
-	return Section{Title: title}
+	return append(ss, Section{Title: title, Content: []string{content}})
 }
 
 func removeEmptyStrings(s []string) []string {
@@ -0,0 +1,35 @@
+package changelog
+
+import (
+	"testing"
+	"versioner/internal/changeset"
+
+	"github.com/Masterminds/semver"
+)
+
+func TestNewEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		cc      changeset.Changesets
+		want    string
+	}{
+		{name: "fix", current: "1.2.3", cc: changeset.Changesets{{Type: "fix", Summary: "a"}}, want: "1.2.4"},
+		{name: "feat", current: "1.2.3", cc: changeset.Changesets{{Type: "feat", Summary: "a"}}, want: "1.3.0"},
+		{name: "breaking feat", current: "0.0.0", cc: changeset.Changesets{{Type: "feat", Breaking: true, Summary: "a"}}, want: "1.0.0"},
+		{name: "breaking fix", current: "1.2.3", cc: changeset.Changesets{{Type: "fix", Summary: "a"}, {Type: "fix", Breaking: true, Summary: "b"}}, want: "2.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewEntry(*semver.MustParse(tt.current), tt.cc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if e.Version != tt.want {
+				t.Errorf("got %s, want %s", e.Version, tt.want)
+			}
+		})
+	}
+}
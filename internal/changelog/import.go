@@ -0,0 +1,335 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"versioner/internal/changeset"
+
+	"github.com/Masterminds/semver"
+)
+
+type Format string
+
+const (
+	FormatVersioner      Format = "versioner"
+	FormatKeepAChangelog Format = "keep-a-changelog"
+	FormatGitChglog      Format = "git-chglog"
+	FormatReleasePlease  Format = "release-please"
+	FormatPlain          Format = "plain"
+)
+
+var (
+	headingRe      = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	versionRe      = regexp.MustCompile(`^\[?v?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)\]?`)
+	dateRe         = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+	bulletRe       = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	conventionalRe = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?(!)?:\s*(.+)$`)
+	linkRefRe      = regexp.MustCompile(`^\[[^\]]+\]:\s*\S+`)
+	anchorRe       = regexp.MustCompile(`^<a name="[^"]*"></a>$`)
+	unreleasedRe   = regexp.MustCompile(`(?i)^\[?unreleased\]?`)
+
+	keepAChangelogRe = regexp.MustCompile(`(?m)^## \[[^\]]+\] - \d{4}-\d{2}-\d{2}`)
+	releasePleaseRe  = regexp.MustCompile(`(?m)^#{2,3} \[[^\]]+\]\([^)]+\) \(\d{4}-\d{2}-\d{2}\)`)
+
+	sectionAliases = map[string]string{
+		"added":                  "feat",
+		"features":               "feat",
+		"feature":                "feat",
+		"fixed":                  "fix",
+		"fixes":                  "fix",
+		"bug fixes":              "fix",
+		"bugfixes":               "fix",
+		"code refactoring":       "refactor",
+		"continuous integration": "ci",
+		"build system":           "ci",
+		"build":                  "ci",
+		"misc":                   "chore",
+		"chores":                 "chore",
+		"miscellaneous chores":   "chore",
+		"other":                  "chore",
+		"others":                 "chore",
+		"reverts":                "revert",
+		"breaking":               "",
+		"breaking change":        "",
+		"breaking changes":       "",
+	}
+)
+
+const miscTitle = "Miscellaneous"
+
+type Unclassified struct {
+	Line int
+	Text string
+}
+
+type Import struct {
+	Format   Format
+	Entries  []Entry
+	Releases []Release
+	// Unreleased are the changes listed below an Unreleased heading, as
+	// changesets for the next version.
+	Unreleased   changeset.Changesets
+	Unclassified []Unclassified
+}
+
+// ImportMarkdown converts a changelog written by another tool into entries and
+// the changes not released yet into changesets, collecting the parts that
+// could not be mapped onto a conventional type.
+func ImportMarkdown(content string) Import {
+	imp := Import{
+		Format: detectFormat(content),
+	}
+
+	var entry *Entry
+	skipping := false
+	// sec is the section below the current heading, last and item point at
+	// the item that continuation lines are appended to.
+	sec, last, item := -1, -1, -1
+
+	flush := func() {
+		if entry != nil && len(entry.Version) == 0 {
+			imp.Unreleased = append(imp.Unreleased, unreleasedChangesets(entry.Sections)...)
+		} else if entry != nil {
+			imp.Entries = append(imp.Entries, *entry)
+		}
+		entry = nil
+		sec, last, item = -1, -1, -1
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, l := range lines {
+		line := strings.TrimRight(l, " \t\r")
+		trimmed := strings.TrimSpace(line)
+
+		if len(trimmed) == 0 || trimmed == commentStr || anchorRe.MatchString(trimmed) || linkRefRe.MatchString(trimmed) {
+			item = -1
+			continue
+		}
+
+		if m := headingRe.FindStringSubmatch(trimmed); m != nil {
+			level, text := len(m[1]), m[2]
+
+			if level == 1 {
+				continue
+			}
+
+			if ver, date, ok := parseVersionHeading(text); ok && level <= 3 {
+				flush()
+				entry = &Entry{Version: ver}
+				imp.Releases = append(imp.Releases, Release{Version: ver, Date: date})
+				skipping = false
+				continue
+			}
+
+			if level == 2 && unreleasedRe.MatchString(text) {
+				flush()
+				entry = &Entry{}
+				skipping = false
+				continue
+			}
+
+			if level == 2 {
+				flush()
+				skipping = true
+				imp.Unclassified = append(imp.Unclassified, Unclassified{Line: i + 1, Text: trimmed})
+				continue
+			}
+
+			if skipping || entry == nil {
+				continue
+			}
+
+			title, ok := classifySection(text)
+			if !ok {
+				imp.Unclassified = append(imp.Unclassified, Unclassified{Line: i + 1, Text: trimmed})
+			}
+
+			entry.Sections = append(entry.Sections, Section{Title: title})
+			sec, last, item = len(entry.Sections)-1, -1, -1
+			continue
+		}
+
+		if skipping || entry == nil {
+			continue
+		}
+
+		text := trimmed
+		if m := bulletRe.FindStringSubmatch(line); m != nil {
+			text = strings.TrimSpace(m[1])
+		} else if item >= 0 && strings.HasPrefix(line, " ") {
+			entry.Sections[last].Content[item] += " " + trimmed
+			continue
+		}
+
+		last = sec
+		if sec < 0 {
+			entry.Sections, last = addUnsectioned(entry.Sections, text, i+1, &imp)
+		} else {
+			entry.Sections[sec].Content = append(entry.Sections[sec].Content, text)
+		}
+
+		item = len(entry.Sections[last].Content) - 1
+	}
+
+	flush()
+
+	for i, e := range imp.Entries {
+		imp.Entries[i].Sections = compactSections(e.Sections)
+	}
+
+	return imp
+}
+
+func detectFormat(content string) Format {
+	switch {
+	case strings.Contains(content, commentStr):
+		return FormatVersioner
+	case strings.Contains(content, `<a name="`):
+		return FormatGitChglog
+	case strings.Contains(content, "keepachangelog.com") || keepAChangelogRe.MatchString(content):
+		return FormatKeepAChangelog
+	case releasePleaseRe.MatchString(content):
+		return FormatReleasePlease
+	}
+
+	return FormatPlain
+}
+
+func parseVersionHeading(text string) (string, string, bool) {
+	m := versionRe.FindStringSubmatch(text)
+	if m == nil {
+		return "", "", false
+	}
+
+	ver, err := semver.NewVersion(m[1])
+	if err != nil {
+		return "", "", false
+	}
+
+	return ver.String(), dateRe.FindString(text), true
+}
+
+func classifySection(text string) (string, bool) {
+	key := strings.ToLower(strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsSpace(r) {
+			return r
+		}
+		return -1
+	}, text)))
+
+	t, ok := sectionAliases[key]
+	if ok && len(t) == 0 {
+		return breakingTitle, true
+	}
+
+	if !ok {
+		t = key
+	}
+
+	for _, ct := range changeset.Types {
+		if ct.Type == t || strings.ToLower(ct.Title) == key {
+			return ct.Title, true
+		}
+	}
+
+	return text, false
+}
+
+// addUnsectioned files an item that is not below any section heading, using
+// a conventional commit prefix when there is one. It returns the index of the
+// section the item ended up in.
+func addUnsectioned(ss []Section, text string, line int, imp *Import) ([]Section, int) {
+	title := ""
+
+	if m := conventionalRe.FindStringSubmatch(text); m != nil {
+		for _, ct := range changeset.Types {
+			if ct.Type == m[1] {
+				title = ct.Title
+				text = m[3]
+				break
+			}
+		}
+
+		if len(title) > 0 && len(m[2]) > 0 {
+			title = breakingTitle
+		}
+	}
+
+	if len(title) == 0 {
+		title = miscTitle
+		imp.Unclassified = append(imp.Unclassified, Unclassified{Line: line, Text: text})
+	}
+
+	ss = addToSection(title, text, ss)
+
+	for i, s := range ss {
+		if s.Title == title {
+			return ss, i
+		}
+	}
+
+	return ss, len(ss) - 1
+}
+
+// unreleasedChangesets turns the items of the sections into changesets, items
+// of sections that match no type become chores.
+func unreleasedChangesets(ss []Section) changeset.Changesets {
+	cc := changeset.Changesets{}
+
+	for _, s := range ss {
+		c := changeset.Changeset{Type: "chore"}
+
+		if s.Title == breakingTitle {
+			c.Type, c.Breaking = "feat", true
+		}
+
+		for _, ct := range changeset.Types {
+			if ct.Title == s.Title {
+				c.Type = ct.Type
+				break
+			}
+		}
+
+		for _, text := range s.Content {
+			c.Summary = text
+			cc = append(cc, c)
+		}
+	}
+
+	return cc
+}
+
+func compactSections(ss []Section) []Section {
+	res := []Section{}
+
+	for _, s := range ss {
+		for _, c := range s.Content {
+			res = addToSection(s.Title, c, res)
+		}
+	}
+
+	return res
+}
+
+// UnknownSections lists the section titles of a changelog written by any tool
+// that no type or alias matches, in the order they first appear.
+func UnknownSections(content string) []string {
+	known := map[string]bool{breakingTitle: true}
+	for _, t := range changeset.Types {
+		known[t.Title] = true
+	}
+
+	titles := []string{}
+
+	for _, e := range ImportMarkdown(content).Entries {
+		for _, s := range e.Sections {
+			if !known[s.Title] {
+				known[s.Title] = true
+				titles = append(titles, s.Title)
+			}
+		}
+	}
+
+	return titles
+}
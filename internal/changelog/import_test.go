@@ -0,0 +1,243 @@
+package changelog
+
+import (
+	"reflect"
+	"testing"
+	"versioner/internal/changeset"
+)
+
+const keepAChangelog = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+
+## [Unreleased]
+
+### Added
+
+- Export to CSV
+
+### Fixed
+
+- Crash on empty input
+
+## [1.1.0] - 2023-03-05
+
+### Added
+
+- Dark mode
+
+### Fixed
+
+- Typo in the help,
+  spotted by a user
+
+## [1.0.0] - 2023-01-01
+
+### Added
+
+- First release
+
+[Unreleased]: https://example.com/compare/v1.1.0...HEAD
+[1.1.0]: https://example.com/compare/v1.0.0...v1.1.0
+[1.0.0]: https://example.com/releases/tag/v1.0.0
+`
+
+const gitChglog = `<a name="unreleased"></a>
+## [Unreleased]
+
+### Features
+- Retry failed uploads
+
+<a name="v1.2.0"></a>
+## [v1.2.0] - 2023-04-01
+### Bug Fixes
+- Close the connection on errors
+
+### Features
+- Upload in parallel
+
+<a name="v1.1.0"></a>
+## v1.1.0 - 2023-02-01
+### Code Refactoring
+- Split the client
+
+[Unreleased]: https://example.com/compare/v1.2.0...HEAD
+`
+
+const releasePlease = `# Changelog
+
+## [2.0.0](https://example.com/compare/v1.0.0...v2.0.0) (2023-06-01)
+
+
+### ⚠ BREAKING CHANGES
+
+* drop the v1 API
+
+### Features
+
+* **api:** add the v2 API ([abc1234](https://example.com/commit/abc1234))
+
+## [1.0.0](https://example.com/compare/v0.1.0...v1.0.0) (2023-05-01)
+
+
+### Bug Fixes
+
+* handle timeouts ([def5678](https://example.com/commit/def5678))
+`
+
+const plainChangelog = `# History
+
+## Unreleased
+
+- feat: add a config file
+- tidy the readme
+
+## 0.2.0 (2023-02-02)
+
+- fix: handle missing files
+- update dependencies
+
+## Roadmap
+
+- world domination
+
+## 0.1.0
+
+- feat!: first release
+`
+
+func TestImportMarkdown(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		format       Format
+		entries      []Entry
+		releases     []Release
+		unreleased   changeset.Changesets
+		unclassified []Unclassified
+	}{
+		{
+			name:    "keep a changelog",
+			content: keepAChangelog,
+			format:  FormatKeepAChangelog,
+			entries: []Entry{
+				{Version: "1.1.0", Sections: []Section{
+					{Title: "New features", Content: []string{"Dark mode"}},
+					{Title: "Bug fixes", Content: []string{"Typo in the help, spotted by a user"}},
+				}},
+				{Version: "1.0.0", Sections: []Section{
+					{Title: "New features", Content: []string{"First release"}},
+				}},
+			},
+			releases: []Release{{Version: "1.1.0", Date: "2023-03-05"}, {Version: "1.0.0", Date: "2023-01-01"}},
+			unreleased: changeset.Changesets{
+				{Type: "feat", Summary: "Export to CSV"},
+				{Type: "fix", Summary: "Crash on empty input"},
+			},
+			unclassified: []Unclassified{},
+		},
+		{
+			name:    "git-chglog",
+			content: gitChglog,
+			format:  FormatGitChglog,
+			entries: []Entry{
+				{Version: "1.2.0", Sections: []Section{
+					{Title: "Bug fixes", Content: []string{"Close the connection on errors"}},
+					{Title: "New features", Content: []string{"Upload in parallel"}},
+				}},
+				{Version: "1.1.0", Sections: []Section{
+					{Title: "Refactoring", Content: []string{"Split the client"}},
+				}},
+			},
+			releases:     []Release{{Version: "1.2.0", Date: "2023-04-01"}, {Version: "1.1.0", Date: "2023-02-01"}},
+			unreleased:   changeset.Changesets{{Type: "feat", Summary: "Retry failed uploads"}},
+			unclassified: []Unclassified{},
+		},
+		{
+			name:    "release-please",
+			content: releasePlease,
+			format:  FormatReleasePlease,
+			entries: []Entry{
+				{Version: "2.0.0", Sections: []Section{
+					{Title: "Breaking changes", Content: []string{"drop the v1 API"}},
+					{Title: "New features", Content: []string{"**api:** add the v2 API ([abc1234](https://example.com/commit/abc1234))"}},
+				}},
+				{Version: "1.0.0", Sections: []Section{
+					{Title: "Bug fixes", Content: []string{"handle timeouts ([def5678](https://example.com/commit/def5678))"}},
+				}},
+			},
+			releases:     []Release{{Version: "2.0.0", Date: "2023-06-01"}, {Version: "1.0.0", Date: "2023-05-01"}},
+			unreleased:   changeset.Changesets{},
+			unclassified: []Unclassified{},
+		},
+		{
+			name:    "plain",
+			content: plainChangelog,
+			format:  FormatPlain,
+			entries: []Entry{
+				{Version: "0.2.0", Sections: []Section{
+					{Title: "Bug fixes", Content: []string{"handle missing files"}},
+					{Title: "Miscellaneous", Content: []string{"update dependencies"}},
+				}},
+				{Version: "0.1.0", Sections: []Section{
+					{Title: "Breaking changes", Content: []string{"first release"}},
+				}},
+			},
+			releases: []Release{{Version: "0.2.0", Date: "2023-02-02"}, {Version: "0.1.0"}},
+			unreleased: changeset.Changesets{
+				{Type: "feat", Summary: "add a config file"},
+				{Type: "chore", Summary: "tidy the readme"},
+			},
+			unclassified: []Unclassified{
+				{Line: 6, Text: "tidy the readme"},
+				{Line: 11, Text: "update dependencies"},
+				{Line: 13, Text: "## Roadmap"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imp := ImportMarkdown(tt.content)
+
+			if imp.Format != tt.format {
+				t.Errorf("format: got %s, want %s", imp.Format, tt.format)
+			}
+
+			if !reflect.DeepEqual(imp.Entries, tt.entries) {
+				t.Errorf("entries:\ngot  %+v\nwant %+v", imp.Entries, tt.entries)
+			}
+
+			if !reflect.DeepEqual(imp.Releases, tt.releases) {
+				t.Errorf("releases:\ngot  %+v\nwant %+v", imp.Releases, tt.releases)
+			}
+
+			if !reflect.DeepEqual(append(changeset.Changesets{}, imp.Unreleased...), tt.unreleased) {
+				t.Errorf("unreleased:\ngot  %+v\nwant %+v", imp.Unreleased, tt.unreleased)
+			}
+
+			if !reflect.DeepEqual(append([]Unclassified{}, imp.Unclassified...), tt.unclassified) {
+				t.Errorf("unclassified:\ngot  %+v\nwant %+v", imp.Unclassified, tt.unclassified)
+			}
+
+			// the written changelog reads back as the same entries
+			c := Changelog{Title: "example", Entries: imp.Entries}
+
+			parsed, err := ParseMarkdown(c.Title, c.Markdown())
+			if err != nil {
+				t.Fatalf("parse the written changelog: %v", err)
+			}
+
+			if !reflect.DeepEqual(parsed.Entries, imp.Entries) {
+				t.Errorf("round trip:\ngot  %+v\nwant %+v", parsed.Entries, imp.Entries)
+			}
+
+			again := ImportMarkdown(c.Markdown())
+			if again.Format != FormatVersioner || !reflect.DeepEqual(again.Entries, imp.Entries) {
+				t.Errorf("import of the written changelog: got %s %+v, want %s %+v", again.Format, again.Entries, FormatVersioner, imp.Entries)
+			}
+		})
+	}
+}
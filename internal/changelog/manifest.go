@@ -0,0 +1,177 @@
+package changelog
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"time"
+	"versioner/internal/changeset"
+	"versioner/internal/config"
+	"versioner/internal/fsutil"
+
+	"github.com/pkg/errors"
+)
+
+const ManifestFileName = "releases.json"
+
+type Release struct {
+	Version    string              `json:"version"`
+	Date       string              `json:"date,omitempty"`
+	Changesets []ReleasedChangeset `json:"changesets,omitempty"`
+}
+
+// ReleasedChangeset keeps the whole changeset, so it can be restored by undo
+// or read by changelog rebuild once the file is gone.
+type ReleasedChangeset struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Breaking bool              `json:"breaking,omitempty"`
+	Summary  string            `json:"summary"`
+	Body     string            `json:"body,omitempty"`
+	Packages []ReleasedPackage `json:"packages,omitempty"`
+}
+
+// ReleasedPackage is a package a released changeset bumped, an empty level
+// bumps nothing.
+type ReleasedPackage struct {
+	Path  string `json:"path"`
+	Level string `json:"level"`
+}
+
+// NewRelease records the changesets consumed by a version released today.
+func NewRelease(version string, cc changeset.Changesets) Release {
+	r := Release{
+		Version: version,
+		Date:    time.Now().Format(time.DateOnly),
+	}
+
+	for _, c := range cc {
+		rc := ReleasedChangeset{
+			Name:     c.Name(),
+			Type:     c.Type,
+			Breaking: c.Breaking,
+			Summary:  c.Summary,
+			Body:     c.Body,
+		}
+
+		for _, p := range c.Packages {
+			rc.Packages = append(rc.Packages, ReleasedPackage{Path: p.Path, Level: p.Level})
+		}
+
+		r.Changesets = append(r.Changesets, rc)
+	}
+
+	return r
+}
+
+// Changeset is the released changeset as it was before the release.
+func (rc ReleasedChangeset) Changeset() changeset.Changeset {
+	c := changeset.Changeset{
+		Type:     rc.Type,
+		Breaking: rc.Breaking,
+		Summary:  rc.Summary,
+		Body:     rc.Body,
+	}
+
+	for _, p := range rc.Packages {
+		c.Packages = append(c.Packages, changeset.Package{Path: p.Path, Level: p.Level})
+	}
+
+	return c
+}
+
+func (r Release) ChangesetList() changeset.Changesets {
+	cc := changeset.Changesets{}
+
+	for _, c := range r.Changesets {
+		cc = append(cc, c.Changeset())
+	}
+
+	cc.Sort()
+
+	return cc
+}
+
+type Manifest struct {
+	Releases []Release `json:"releases"`
+	path     string
+}
+
+func ReadManifest(wd string) (Manifest, error) {
+	m := Manifest{
+		Releases: []Release{},
+		path:     path.Join(wd, config.Dir, ManifestFileName),
+	}
+
+	b, err := os.ReadFile(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+
+	if err != nil {
+		return m, errors.Wrap(err, "could not read release manifest")
+	}
+
+	if err = json.Unmarshal(b, &m); err != nil {
+		return m, errors.Wrap(err, "could not read release manifest")
+	}
+
+	return m, nil
+}
+
+func (m Manifest) Save() error {
+	b, err := json.MarshalIndent(&m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(fsutil.WriteFile(m.path, b), "could not save release manifest")
+}
+
+// Add puts the release on top of the manifest, replacing any earlier record
+// of the same version.
+func (m *Manifest) Add(r Release) {
+	releases := []Release{r}
+
+	for _, rel := range m.Releases {
+		if rel.Version != r.Version {
+			releases = append(releases, rel)
+		}
+	}
+
+	m.Releases = releases
+}
+
+func (m *Manifest) Remove(version string) {
+	releases := []Release{}
+
+	for _, r := range m.Releases {
+		if r.Version != version {
+			releases = append(releases, r)
+		}
+	}
+
+	m.Releases = releases
+}
+
+func (m Manifest) Release(version string) (Release, bool) {
+	for _, r := range m.Releases {
+		if r.Version == version {
+			return r, true
+		}
+	}
+
+	return Release{}, false
+}
+
+// Prune drops the changesets of all but the keep latest releases, the release
+// records themselves are kept. Keep zero means that nothing is pruned.
+func (m *Manifest) Prune(keep int) {
+	if keep <= 0 {
+		return
+	}
+
+	for i := keep; i < len(m.Releases); i++ {
+		m.Releases[i].Changesets = nil
+	}
+}
@@ -0,0 +1,60 @@
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"versioner/internal/changeset"
+	"versioner/internal/config"
+)
+
+func TestManifestKeepsChangesets(t *testing.T) {
+	files := map[string]string{
+		"brave_turing": "---\nfix\n---\n\nHandle timeouts\n\n",
+		"calm_hopper":  "---\nfeat!\napi: major\ncli: none\n---\n\nDrop the v1 API\n\nThe v2 API replaces it,\nsee the migration guide.\n\n",
+	}
+
+	cc := changeset.Changesets{}
+	for _, name := range []string{"calm_hopper", "brave_turing"} {
+		c, err := changeset.Parse(files[name], ".versioner/"+name+".md")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cc = append(cc, c)
+	}
+
+	wd := t.TempDir()
+	if err := os.Mkdir(filepath.Join(wd, config.Dir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ReadManifest(wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Add(NewRelease("1.0.0", cc))
+	if err = m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if m, err = ReadManifest(wd); err != nil {
+		t.Fatal(err)
+	}
+
+	rel, ok := m.Release("1.0.0")
+	if !ok {
+		t.Fatal("release 1.0.0 is not in the manifest")
+	}
+
+	if len(rel.Changesets) != len(files) {
+		t.Fatalf("got %d changesets, want %d", len(rel.Changesets), len(files))
+	}
+
+	for _, rc := range rel.Changesets {
+		if got := rc.Changeset().Markdown(); got != files[rc.Name] {
+			t.Errorf("%s:\n%q\nwant:\n%q", rc.Name, got, files[rc.Name])
+		}
+	}
+}
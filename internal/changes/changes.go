@@ -0,0 +1,179 @@
+package changes
+
+import (
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoBaseBranch = errors.New("no base branch configured, set baseBranch in config")
+	ErrNoMergeBase  = errors.New("HEAD and the base branch have no common history")
+)
+
+type File struct {
+	Path   string
+	Action merkletrie.Action
+	// Hash is the blob of the file at HEAD, zero for deleted files.
+	Hash plumbing.Hash
+}
+
+// SinceBase lists the files changed on HEAD since it forked from the base
+// branch, the local branch is used before the one on origin.
+func SinceBase(repo *git.Repository, baseBranch string) ([]File, error) {
+	if len(baseBranch) == 0 {
+		return nil, ErrNoBaseBranch
+	}
+
+	h, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := repo.CommitObject(h.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	baseRef, err := repo.Reference(plumbing.NewBranchReferenceName(baseBranch), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		baseRef, err = repo.Reference(plumbing.NewRemoteReferenceName("origin", baseBranch), true)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find base branch %s", baseBranch)
+	}
+
+	base, err := repo.CommitObject(baseRef.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	bases, err := head.MergeBase(base)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bases) == 0 {
+		return nil, ErrNoMergeBase
+	}
+
+	from, err := bases[0].Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := head.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := object.DiffTree(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []File{}
+
+	for _, c := range diff {
+		action, err := c.Action()
+		if err != nil {
+			return nil, err
+		}
+
+		f := File{
+			Path:   c.To.Name,
+			Action: action,
+			Hash:   c.To.TreeEntry.Hash,
+		}
+
+		if action == merkletrie.Delete {
+			f.Path = c.From.Name
+		}
+
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// Ignored reports if the file matches one of the glob patterns, either by its
+// full path, its name or one of the directories it is in.
+func Ignored(p string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, "/**"), "/")
+
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, path.Base(p)); ok {
+			return true
+		}
+
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			if ok, _ := path.Match(pattern, dir); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Packages lists the package paths that have changed files, a file belongs
+// to the deepest package containing it and "." is the package at the root.
+func Packages(files []File, paths []string) []string {
+	changed := map[string]bool{}
+
+	for _, f := range files {
+		owner := ""
+
+		for _, p := range paths {
+			if p != "." && !strings.HasPrefix(f.Path, p+"/") {
+				continue
+			}
+
+			if len(owner) == 0 || owner == "." || len(p) > len(owner) {
+				owner = p
+			}
+		}
+
+		if len(owner) > 0 {
+			changed[owner] = true
+		}
+	}
+
+	pp := []string{}
+	for _, p := range paths {
+		if changed[p] {
+			pp = append(pp, p)
+		}
+	}
+
+	return pp
+}
+
+// Within keeps the files inside dir, with their paths relative to dir, an
+// empty dir is the whole repository.
+func Within(files []File, dir string) []File {
+	if len(dir) == 0 {
+		return files
+	}
+
+	within := []File{}
+
+	for _, f := range files {
+		if rel, ok := strings.CutPrefix(f.Path, dir+"/"); ok {
+			f.Path = rel
+			within = append(within, f)
+		}
+	}
+
+	return within
+}
@@ -0,0 +1,116 @@
+package changeset
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"versioner/internal/config"
+	"versioner/internal/fsutil"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+)
+
+func ArchivePath(wd, version string) string {
+	return path.Join(wd, config.Dir, ReleasedDir, version)
+}
+
+// Archive moves the changesets into the released directory of the version
+// instead of removing them.
+func (cc Changesets) Archive(wd, version string) error {
+	dir := ArchivePath(wd, version)
+
+	if err := fsutil.MkdirAll(dir); err != nil {
+		return errors.Wrap(err, "could not create archive")
+	}
+
+	for _, c := range cc {
+		if len(c.path) == 0 {
+			continue
+		}
+
+		if err := os.Rename(c.path, path.Join(dir, path.Base(c.path))); err != nil {
+			return errors.Wrap(err, "could not archive changeset")
+		}
+	}
+
+	return nil
+}
+
+// Unarchive moves the archived changesets of the version back so they can be
+// released again.
+func Unarchive(wd, version string) error {
+	dir := ArchivePath(wd, version)
+
+	matches, err := filepath.Glob(path.Join(dir, "*.md"))
+	if err != nil {
+		return err
+	}
+
+	for _, m := range matches {
+		if err = os.Rename(m, path.Join(wd, config.Dir, path.Base(m))); err != nil {
+			return errors.Wrap(err, "could not restore archived changeset")
+		}
+	}
+
+	return os.RemoveAll(dir)
+}
+
+// Archived reads the changesets archived for the version, none are returned
+// when the version has no archive.
+func Archived(wd, version string) (Changesets, error) {
+	matches, err := filepath.Glob(path.Join(ArchivePath(wd, version), "*.md"))
+	if err != nil {
+		return Changesets{}, err
+	}
+
+	cc, err := parseChangesets(matches)
+	if err != nil {
+		return cc, err
+	}
+
+	Changesets(cc).Sort()
+
+	return cc, nil
+}
+
+// PruneArchive removes the archives of all but the keep latest versions,
+// keep zero means that every archive is kept.
+func PruneArchive(wd string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(path.Join(wd, config.Dir, ReleasedDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	versions := []*semver.Version{}
+	names := map[*semver.Version]string{}
+
+	for _, e := range entries {
+		v, err := semver.NewVersion(e.Name())
+		if !e.IsDir() || err != nil {
+			continue
+		}
+
+		versions = append(versions, v)
+		names[v] = e.Name()
+	}
+
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+
+	for i := keep; i < len(versions); i++ {
+		if err := os.RemoveAll(ArchivePath(wd, names[versions[i]])); err != nil {
+			return errors.Wrap(err, "could not prune archive")
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,11 @@ package changeset
 import (
 	"fmt"
 	"os"
+	"path"
+	"sort"
+	"strings"
+	"versioner/internal/config"
+	"versioner/internal/fsutil"
 
 	"github.com/pkg/errors"
 )
@@ -68,9 +73,21 @@ type Changeset struct {
 	Breaking bool
 	Type     string
 	Summary  string
+	// Body is the optional text written below the summary, it is kept in the
+	// changeset file but never ends up in the changelog.
+	Body string
+	// Packages are the packages of a monorepo the changeset affects, empty
+	// when the changeset is for the whole project.
+	Packages []Package
 	path     string
 }
 
+// Package is a package affected by a changeset and the level it is bumped.
+type Package struct {
+	Path  string
+	Level string
+}
+
 func (c Changeset) ConventionalType() (ConventionalType, error) {
 	for _, t := range Types {
 		if t.Type == c.Type {
@@ -81,15 +98,57 @@ func (c Changeset) ConventionalType() (ConventionalType, error) {
 	return ConventionalType{}, errors.Wrap(ErrConventionalTypeNotFound, fmt.Sprintf("%s:", c.Type))
 }
 
+// Name is the file name of the changeset without extension.
+func (c Changeset) Name() string {
+	return strings.TrimSuffix(path.Base(c.path), ".md")
+}
+
+func (c Changeset) Path() string {
+	return c.path
+}
+
 func (c Changeset) Save(wd string) error {
+	return writeChangesetFile(wd, c.Markdown())
+}
+
+// SaveAs writes the changeset under a given name, used to bring back
+// changesets that were already consumed by a release and to rewrite a pending
+// changeset in place.
+func (c Changeset) SaveAs(wd, name string) error {
+	return fsutil.WriteFile(path.Join(wd, config.Dir, name+".md"), []byte(c.Markdown()))
+}
+
+func (c Changeset) Markdown() string {
 	release := c.Type
 	if c.Breaking {
 		release += "!"
 	}
 
-	content := fmt.Sprintf(mdTemplate, release, c.Summary)
+	for _, p := range c.Packages {
+		level := p.Level
+		if level == None {
+			level = "none"
+		}
+
+		release += fmt.Sprintf("\n%s: %s", p.Path, level)
+	}
+
+	text := c.Summary
+	if len(c.Body) > 0 {
+		text += "\n\n" + c.Body
+	}
 
-	return writeChangesetFile(wd, content)
+	return fmt.Sprintf(mdTemplate, release, text)
+}
+
+// SplitSummary splits text into the summary on its first line and the body
+// made up of the rest.
+func SplitSummary(text string) (string, string) {
+	text = strings.TrimSpace(text)
+
+	summary, body, _ := strings.Cut(text, "\n")
+
+	return strings.TrimSpace(summary), strings.TrimSpace(body)
 }
 
 func (c Changeset) Remove() error {
@@ -102,6 +161,8 @@ func (c Changeset) Remove() error {
 
 type Changesets []Changeset
 
+// HighestLevel is the semver level the changesets bump together, a breaking
+// change bumps major whatever the level of its type.
 func (cc Changesets) HighestLevel() (string, error) {
 	level := Patch
 
@@ -110,8 +171,14 @@ func (cc Changesets) HighestLevel() (string, error) {
 		if err != nil {
 			return level, errors.Wrap(err, "could not calculate highest semver level")
 		}
-		if isLevelHigher(level, ct.Level) {
-			level = ct.Level
+
+		l := ct.Level
+		if c.Breaking {
+			l = Major
+		}
+
+		if isLevelHigher(level, l) {
+			level = l
 		}
 	}
 
@@ -140,6 +207,11 @@ func (cc Changesets) Remove() error {
 	return nil
 }
 
+// Sort orders the changesets the same way as they are ordered in the changelog.
+func (cc Changesets) Sort() {
+	sort.Sort(byOrder(cc))
+}
+
 type byOrder Changesets
 
 func (a byOrder) Len() int { return len(a) }
@@ -162,6 +234,44 @@ type ConventionalType struct {
 
 type ConventionalTypes []ConventionalType
 
+// Register adds the custom types from the config to Types, a custom type with
+// the key of a known type replaces it but keeps its place in the changelog.
+func Register(types []config.Type) error {
+	for _, t := range types {
+		if len(t.Type) == 0 || len(t.Title) == 0 {
+			return errors.Errorf("custom type %q needs both a type and a title", t.Type)
+		}
+
+		level, ok := ParseLevel(t.Level)
+		if !ok {
+			return errors.Errorf("custom type %q has unknown level %q, use major, minor, patch or none", t.Type, t.Level)
+		}
+
+		ct := ConventionalType{
+			Title:         t.Title,
+			Type:          t.Type,
+			CanBeBreaking: t.CanBeBreaking,
+			Level:         level,
+			Order:         len(Types),
+		}
+
+		replaced := false
+		for i, known := range Types {
+			if known.Type == ct.Type {
+				ct.Order = known.Order
+				Types[i] = ct
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			Types = append(Types, ct)
+		}
+	}
+
+	return nil
+}
+
 func (c ConventionalTypes) CanBeBreaking(t string) bool {
 	for _, ct := range c {
 		if ct.Type == t {
@@ -172,6 +282,21 @@ func (c ConventionalTypes) CanBeBreaking(t string) bool {
 	return false
 }
 
+// ParseLevel reads a semver level where none, like an empty level, means that
+// nothing is bumped.
+func ParseLevel(level string) (string, bool) {
+	level = strings.ToLower(strings.TrimSpace(level))
+
+	switch level {
+	case Major, Minor, Patch, None:
+		return level, true
+	case "none":
+		return None, true
+	}
+
+	return level, false
+}
+
 func isLevelHigher(base, comp string) bool {
 	if base == Major {
 		return false
@@ -0,0 +1,31 @@
+package changeset
+
+import "testing"
+
+func TestHighestLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		cc   Changesets
+		want string
+	}{
+		{name: "no changesets", cc: Changesets{}, want: Patch},
+		{name: "fix", cc: Changesets{{Type: "fix"}}, want: Patch},
+		{name: "feat over fix", cc: Changesets{{Type: "fix"}, {Type: "feat"}}, want: Minor},
+		{name: "breaking fix", cc: Changesets{{Type: "fix", Breaking: true}}, want: Major},
+		{name: "breaking feat", cc: Changesets{{Type: "feat", Breaking: true}, {Type: "fix"}}, want: Major},
+		{name: "breaking after feat", cc: Changesets{{Type: "feat"}, {Type: "revert", Breaking: true}}, want: Major},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cc.HighestLevel()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,11 +9,14 @@ import (
 	"sort"
 	"strings"
 	"versioner/internal/config"
+	"versioner/internal/fsutil"
 
 	"github.com/docker/docker/pkg/namesgenerator"
 	"github.com/pkg/errors"
 )
 
+const ReleasedDir = "released"
+
 const mdTemplate = `---
 %s
 ---
@@ -33,6 +36,10 @@ func ParseChangesets(wd string) (Changesets, error) {
 			return e
 		}
 
+		if d.IsDir() && d.Name() == ReleasedDir {
+			return filepath.SkipDir
+		}
+
 		if filepath.Ext(d.Name()) == ".md" {
 			changesetPaths = append(changesetPaths, s)
 		}
@@ -55,7 +62,7 @@ func writeChangesetFile(wd, content string) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(p, []byte(content), os.ModePerm)
+	return fsutil.WriteFile(p, []byte(content))
 }
 
 func parseChangesets(paths []string) ([]Changeset, error) {
@@ -66,20 +73,20 @@ func parseChangesets(paths []string) ([]Changeset, error) {
 			return changesets, err
 		}
 
-		c, err := parseChangeset(string(b), p)
+		c, err := Parse(string(b), p)
 		if err != nil {
 			return changesets, err
 		}
 
-		c.path = p
-
 		changesets = append(changesets, c)
 	}
 
 	return changesets, nil
 }
 
-func parseChangeset(str, file string) (Changeset, error) {
+// Parse reads a single changeset from its markdown content, file is only used
+// to point out which changeset is malformated.
+func Parse(str, file string) (Changeset, error) {
 	if len(str) < 4 {
 		return Changeset{}, errors.Wrap(ErrChangesetMalformated, fmt.Sprintf("could not parse changeset '%s'", file))
 	}
@@ -91,34 +98,56 @@ func parseChangeset(str, file string) (Changeset, error) {
 		return Changeset{}, errors.Wrap(ErrChangesetMalformated, fmt.Sprintf("could not parse changeset '%s'", file))
 	}
 
-	conType := str[:endSectionIndex]
+	conType, packages, _ := strings.Cut(str[:endSectionIndex], "\n")
+
+	pp, err := parsePackages(packages)
+	if err != nil {
+		return Changeset{}, errors.Wrap(ErrChangesetMalformated, fmt.Sprintf("could not parse changeset '%s': %s", file, err))
+	}
 
 	str = str[endSectionIndex+4:]
 
-	rest := removeEmptyStrings(strings.Split(str, "\n"))
+	summary, body := SplitSummary(str)
 
-	if len(rest) == 0 {
+	if len(summary) == 0 {
 		return Changeset{}, errors.Wrap(ErrChangesetMalformated, fmt.Sprintf("could not parse changeset '%s'", file))
 	}
 
-	summary := rest[0]
-
 	return Changeset{
 		Type:     strings.ReplaceAll(conType, "!", ""),
 		Summary:  summary,
+		Body:     body,
 		Breaking: strings.Contains(conType, "!"),
+		Packages: pp,
+		path:     file,
 	}, nil
 }
 
-func removeEmptyStrings(s []string) []string {
-	var r []string
-	for _, str := range s {
-		if str != "" {
-			r = append(r, str)
+// parsePackages reads the packages listed below the type in the front matter
+// as "path: level" lines.
+func parsePackages(str string) ([]Package, error) {
+	pp := []Package{}
+
+	for _, l := range strings.Split(str, "\n") {
+		l = strings.TrimSpace(l)
+		if len(l) == 0 {
+			continue
+		}
+
+		p, level, ok := strings.Cut(l, ":")
+		if !ok {
+			return pp, errors.Errorf("package %q has no level", l)
 		}
+
+		l, ok := ParseLevel(level)
+		if !ok {
+			return pp, errors.Errorf("package %q has unknown level %q", p, strings.TrimSpace(level))
+		}
+
+		pp = append(pp, Package{Path: strings.TrimSpace(p), Level: l})
 	}
 
-	return r
+	return pp, nil
 }
 
 func generateUniquePath(wd string) (string, error) {
@@ -0,0 +1,111 @@
+package changeset
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+	"versioner/internal/config"
+	"versioner/internal/fsutil"
+
+	"github.com/pkg/errors"
+)
+
+const UndoFileName = "undo.json"
+
+// UndoRecord keeps what undoing a version needs and git may not have, the
+// changesets it consumed and the commit it made. It lives outside the
+// worktree so it is never committed.
+type UndoRecord struct {
+	Version string `json:"version"`
+	// Changesets are the files consumed by the version by their name.
+	Changesets map[string]string `json:"changesets"`
+	// Commit is the commit made by the version, empty when none was made.
+	Commit string `json:"commit,omitempty"`
+	Amend  bool   `json:"amend,omitempty"`
+	path   string
+}
+
+// NewUndoRecord reads the files of the changesets the version consumes.
+func NewUndoRecord(dir, version string, cc Changesets) (UndoRecord, error) {
+	r := UndoRecord{
+		Version:    version,
+		Changesets: map[string]string{},
+		path:       path.Join(dir, UndoFileName),
+	}
+
+	for _, c := range cc {
+		b, err := os.ReadFile(c.path)
+		if err != nil {
+			return r, errors.Wrap(err, "could not record changeset for undo")
+		}
+
+		r.Changesets[c.Name()] = string(b)
+	}
+
+	return r, nil
+}
+
+// ReadUndoRecord reads the record of the last version, false when there is
+// none for the version.
+func ReadUndoRecord(dir, version string) (UndoRecord, bool, error) {
+	r := UndoRecord{path: path.Join(dir, UndoFileName)}
+
+	b, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, false, nil
+	}
+
+	if err != nil {
+		return r, false, errors.Wrap(err, "could not read undo record")
+	}
+
+	if err = json.Unmarshal(b, &r); err != nil {
+		return r, false, errors.Wrap(err, "could not read undo record")
+	}
+
+	return r, r.Version == version, nil
+}
+
+func (r UndoRecord) Save() error {
+	b, err := json.MarshalIndent(&r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = fsutil.MkdirAll(path.Dir(r.path)); err != nil {
+		return err
+	}
+
+	return errors.Wrap(fsutil.WriteFile(r.path, b), "could not save undo record")
+}
+
+// Restore writes back the recorded changesets that are missing in wd.
+func (r UndoRecord) Restore(wd string) error {
+	names := make([]string, 0, len(r.Changesets))
+	for name := range r.Changesets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := path.Join(wd, config.Dir, name+".md")
+		if _, err := os.Stat(p); !errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err := fsutil.WriteFile(p, []byte(r.Changesets[name])); err != nil {
+			return errors.Wrap(err, "could not restore changeset")
+		}
+	}
+
+	return nil
+}
+
+func (r UndoRecord) Remove() error {
+	if err := os.Remove(r.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
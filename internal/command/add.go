@@ -1,18 +1,27 @@
 package command
 
 import (
+	"fmt"
+	"os"
+	"versioner/internal/changes"
+	"versioner/internal/changeset"
 	"versioner/internal/config"
 	"versioner/internal/context"
 	"versioner/internal/detect"
+	"versioner/internal/editor"
 	"versioner/internal/tui"
 
 	"github.com/pkg/errors"
 )
 
-type Add struct{}
+type Add struct {
+	Editor bool `help:"Write the changeset in $VISUAL or $EDITOR instead of the prompts"`
+	Plain  bool `help:"Ask line by line instead of full screen, the default without a terminal"`
+}
 
 func (a Add) Run(ctx *context.Context) error {
-	if err := config.Ensure(ctx.Wd()); err != nil {
+	conf, err := config.Read(ctx.Wd())
+	if err != nil {
 		return err
 	}
 
@@ -21,14 +30,73 @@ func (a Add) Run(ctx *context.Context) error {
 		return err
 	}
 
-	change, abort, err := tui.NewAddProgram(project)
+	packages, err := detect.Packages(ctx.Wd())
 	if err != nil {
 		return err
 	}
 
-	if abort {
-		return nil
+	var change changeset.Changeset
+
+	if !a.Editor && (a.Plain || tui.Plain()) {
+		change, err = tui.NewPlainAddProgram(os.Stdin, os.Stdout, packages, changedPackages(ctx, conf, packages))
+		if err != nil {
+			return err
+		}
+	} else if !a.Editor {
+		var abort bool
+
+		change, abort, err = tui.NewAddProgram(project, conf, packages, changedPackages(ctx, conf, packages))
+		if abort {
+			return nil
+		}
+
+		if err != nil && !errors.Is(err, tui.ErrSwitchToEditor) {
+			return err
+		}
+	}
+
+	if a.Editor || errors.Is(err, tui.ErrSwitchToEditor) {
+		if change, err = editor.Compose(change, retryEdit); err != nil {
+			return err
+		}
 	}
 
 	return errors.Wrap(change.Save(ctx.Wd()), "could not save new changeset")
 }
+
+// changedPackages are the packages with changes since the base branch, none
+// are returned when the changes cannot be found as they are only a hint.
+func changedPackages(ctx *context.Context, conf config.Configuration, packages []detect.Project) []string {
+	if len(packages) < 2 {
+		return nil
+	}
+
+	files, err := changes.SinceBase(ctx.Repo(), conf.BaseBranch)
+	if err != nil {
+		return nil
+	}
+
+	files = ownFiles(ctx.Wd(), changes.Within(files, ctx.Dir()))
+
+	paths := make([]string, len(packages))
+	for i, p := range packages {
+		paths[i] = p.Path
+	}
+
+	changed := []changes.File{}
+	for _, f := range files {
+		if !changes.Ignored(f.Path, conf.Ignore) {
+			changed = append(changed, f)
+		}
+	}
+
+	return changes.Packages(changed, paths)
+}
+
+func retryEdit(err error) bool {
+	fmt.Println(err)
+
+	ok, err := tui.Confirm("The changeset could not be read, edit it again?")
+
+	return err == nil && ok
+}
@@ -0,0 +1,284 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"versioner/internal/changelog"
+	"versioner/internal/changeset"
+	"versioner/internal/config"
+	"versioner/internal/context"
+	"versioner/internal/detect"
+	"versioner/internal/tags"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+	"github.com/pkg/errors"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+var ErrChangelogOutOfDate = errors.New("changelog does not match the git history")
+
+type Changelog struct {
+	Import  ChangelogImport  `cmd:"" help:"Convert an existing changelog into the versioner format"`
+	Rebuild ChangelogRebuild `cmd:"" help:"Regenerate the changelog from the changesets released in each version tag"`
+}
+
+type ChangelogImport struct {
+	File   string `arg:"" optional:"" help:"Changelog to import, defaults to the changelog of the config"`
+	DryRun bool   `help:"Print the converted changelog instead of writing it"`
+}
+
+func (i ChangelogImport) Run(ctx *context.Context) error {
+	conf, err := config.Read(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	file := i.File
+	if len(file) == 0 {
+		file = path.Join(ctx.Wd(), changelog.File(conf))
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return errors.Wrap(err, "could not read changelog to import")
+	}
+
+	project, err := detect.Run(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	imp := changelog.ImportMarkdown(string(b))
+
+	c := changelog.Changelog{
+		Title:   project.Name,
+		Entries: imp.Entries,
+		Path:    path.Join(ctx.Wd(), changelog.File(conf)),
+	}
+
+	if i.DryRun {
+		fmt.Print(c.Markdown())
+	} else {
+		if err = c.Save(); err != nil {
+			return err
+		}
+
+		m, err := changelog.ReadManifest(ctx.Wd())
+		if err != nil {
+			return err
+		}
+
+		for j := len(imp.Releases) - 1; j >= 0; j-- {
+			m.Add(imp.Releases[j])
+		}
+
+		if err = m.Save(); err != nil {
+			return err
+		}
+
+		for _, c := range imp.Unreleased {
+			if err = c.Save(ctx.Wd()); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("imported %d releases from a %s changelog\n", len(imp.Entries), imp.Format)
+
+	if len(imp.Unreleased) > 0 && i.DryRun {
+		fmt.Printf("would add %d changesets for the unreleased changes\n", len(imp.Unreleased))
+	} else if len(imp.Unreleased) > 0 {
+		fmt.Printf("added %d changesets for the unreleased changes\n", len(imp.Unreleased))
+	}
+
+	if len(imp.Unclassified) > 0 {
+		fmt.Println("could not classify:")
+		for _, u := range imp.Unclassified {
+			fmt.Printf("  %s:%d: %s\n", file, u.Line, u.Text)
+		}
+	}
+
+	return nil
+}
+
+type ChangelogRebuild struct {
+	Check bool `help:"Only compare the rebuilt changelog with the current one"`
+}
+
+func (r ChangelogRebuild) Run(ctx *context.Context) error {
+	conf, err := config.Read(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	project, err := detect.Run(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	idx, err := tags.New(ctx.Repo(), conf.TagTemplate)
+	if err != nil {
+		return err
+	}
+
+	c := changelog.Changelog{
+		Title: project.Name,
+		Path:  path.Join(ctx.Wd(), changelog.File(conf)),
+	}
+
+	m, err := changelog.ReadManifest(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	for _, t := range idx.Versions() {
+		cc, err := r.released(ctx, m, t)
+		if err != nil {
+			return errors.Wrapf(err, "could not read changesets released in %s", t.Name)
+		}
+
+		if len(cc) == 0 {
+			fmt.Printf("no changesets found for %s\n", t.Name)
+		}
+
+		e, err := changelog.EntryForVersion(*t.Version, cc)
+		if err != nil {
+			return err
+		}
+
+		c.Add(e)
+	}
+
+	if !r.Check {
+		return c.Save()
+	}
+
+	b, err := os.ReadFile(c.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if string(b) == c.Markdown() {
+		fmt.Printf("%s is up to date\n", changelog.File(conf))
+		return nil
+	}
+
+	fmt.Print(lineDiff(string(b), c.Markdown()))
+
+	return ErrChangelogOutOfDate
+}
+
+// released finds the changesets of a version, looking in the archive and the
+// release manifest before falling back to the git history.
+func (r ChangelogRebuild) released(ctx *context.Context, m changelog.Manifest, t tags.Tag) (changeset.Changesets, error) {
+	cc, err := changeset.Archived(ctx.Wd(), t.Version.String())
+	if err != nil || len(cc) > 0 {
+		return cc, err
+	}
+
+	if rel, ok := m.Release(t.Version.String()); ok && len(rel.Changesets) > 0 {
+		return rel.ChangesetList(), nil
+	}
+
+	commit, err := ctx.Repo().CommitObject(t.Commit)
+	if err != nil {
+		return cc, err
+	}
+
+	return releasedChangesets(commit, ctx.RepoPath(config.Dir))
+}
+
+// releasedChangesets reads the changesets that were removed from dir in the
+// release commit, which is where versioner consumes them.
+func releasedChangesets(commit *object.Commit, dir string) (changeset.Changesets, error) {
+	cc := changeset.Changesets{}
+
+	if commit.NumParents() == 0 {
+		return cc, nil
+	}
+
+	parent, err := commit.Parent(0)
+	if err != nil {
+		return cc, err
+	}
+
+	from, err := parent.Tree()
+	if err != nil {
+		return cc, err
+	}
+
+	to, err := commit.Tree()
+	if err != nil {
+		return cc, err
+	}
+
+	changes, err := object.DiffTree(from, to)
+	if err != nil {
+		return cc, err
+	}
+
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return cc, err
+		}
+
+		name := change.From.Name
+		if action != merkletrie.Delete || path.Dir(name) != dir || path.Ext(name) != ".md" {
+			continue
+		}
+
+		f, err := from.File(name)
+		if err != nil {
+			return cc, err
+		}
+
+		content, err := f.Contents()
+		if err != nil {
+			return cc, err
+		}
+
+		c, err := changeset.Parse(content, name)
+		if err != nil {
+			return cc, err
+		}
+
+		cc = append(cc, c)
+	}
+
+	cc.Sort()
+
+	return cc, nil
+}
+
+func lineDiff(a, b string) string {
+	dmp := diffmatchpatch.New()
+	ca, cb, lines := dmp.DiffLinesToChars(a, b)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(ca, cb, false), lines)
+
+	var sb strings.Builder
+
+	for _, d := range diffs {
+		prefix := ""
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		default:
+			continue
+		}
+
+		for _, l := range strings.SplitAfter(d.Text, "\n") {
+			if len(l) == 0 {
+				continue
+			}
+			sb.WriteString(prefix + strings.TrimSuffix(l, "\n") + "\n")
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,222 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"versioner/internal/changeset"
+	"versioner/internal/config"
+	"versioner/internal/context"
+	"versioner/internal/detect"
+	"versioner/internal/editor"
+	"versioner/internal/fsutil"
+	"versioner/internal/tui"
+
+	"github.com/pkg/errors"
+	"github.com/sahilm/fuzzy"
+)
+
+var (
+	ErrChangesetNotFound  = errors.New("no changeset matches")
+	ErrChangesetAmbiguous = errors.New("several changesets match, use more of the name")
+)
+
+type Changeset struct {
+	List ChangesetList `cmd:"" help:"Lists the pending changesets"`
+	Show ChangesetShow `cmd:"" help:"Prints a changeset"`
+	Edit ChangesetEdit `cmd:"" help:"Edits a changeset"`
+	Rm   ChangesetRm   `cmd:"" help:"Removes a changeset"`
+}
+
+type ChangesetList struct {
+	JSON bool `help:"Print the changesets as JSON"`
+}
+
+type changesetJSON struct {
+	Name     string `json:"name"`
+	File     string `json:"file"`
+	Type     string `json:"type"`
+	Breaking bool   `json:"breaking"`
+	Summary  string `json:"summary"`
+}
+
+func (l ChangesetList) Run(ctx *context.Context) error {
+	cc, err := readChangesets(ctx)
+	if err != nil {
+		return err
+	}
+
+	if l.JSON {
+		list := []changesetJSON{}
+		for _, c := range cc {
+			list = append(list, changesetJSON{
+				Name:     c.Name(),
+				File:     c.Path(),
+				Type:     c.Type,
+				Breaking: c.Breaking,
+				Summary:  c.Summary,
+			})
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(list)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tBREAKING\tSUMMARY")
+
+	for _, c := range cc {
+		breaking := ""
+		if c.Breaking {
+			breaking = "yes"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name(), c.Type, breaking, c.Summary)
+	}
+
+	return w.Flush()
+}
+
+type ChangesetShow struct {
+	Name string `arg:"" help:"Name of the changeset, fuzzy matched"`
+}
+
+func (s ChangesetShow) Run(ctx *context.Context) error {
+	c, err := findChangeset(ctx, s.Name)
+	if err != nil {
+		return err
+	}
+
+	b, err := os.ReadFile(c.Path())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n\n%s", c.Name(), b)
+
+	return nil
+}
+
+type ChangesetEdit struct {
+	Name   string `arg:"" help:"Name of the changeset, fuzzy matched"`
+	Editor bool   `help:"Open the changeset in $VISUAL or $EDITOR instead of the prompts"`
+	Plain  bool   `help:"Ask line by line instead of full screen, the default without a terminal"`
+}
+
+func (e ChangesetEdit) Run(ctx *context.Context) error {
+	c, err := findChangeset(ctx, e.Name)
+	if err != nil {
+		return err
+	}
+
+	if e.Editor {
+		_, err = editor.Edit(c.Path(), retryEdit)
+		return err
+	}
+
+	conf, err := config.Read(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	project, err := detect.Run(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	packages, err := detect.Packages(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	var change changeset.Changeset
+
+	if e.Plain || tui.Plain() {
+		if change, err = tui.NewPlainEditProgram(os.Stdin, os.Stdout, packages, c); err != nil {
+			return err
+		}
+	} else {
+		var abort bool
+
+		change, abort, err = tui.NewEditProgram(project, conf, packages, c)
+		if err != nil || abort {
+			return err
+		}
+	}
+
+	return errors.Wrap(fsutil.WriteFile(c.Path(), []byte(change.Markdown())), "could not save changeset")
+}
+
+type ChangesetRm struct {
+	Name string `arg:"" help:"Name of the changeset, fuzzy matched"`
+	Yes  bool   `short:"y" help:"Remove without asking for confirmation"`
+}
+
+func (r ChangesetRm) Run(ctx *context.Context) error {
+	c, err := findChangeset(ctx, r.Name)
+	if err != nil {
+		return err
+	}
+
+	if !r.Yes {
+		ok, err := tui.Confirm(fmt.Sprintf("Remove %s (%s: %s)?", c.Name(), c.Type, c.Summary))
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	if err = c.Remove(); err != nil {
+		return err
+	}
+
+	fmt.Printf("removed %s\n", path.Base(c.Path()))
+
+	return nil
+}
+
+func readChangesets(ctx *context.Context) (changeset.Changesets, error) {
+	if err := config.Ensure(ctx.Wd()); err != nil {
+		return nil, err
+	}
+
+	return changeset.ParseChangesets(ctx.Wd())
+}
+
+// findChangeset picks the changeset by its generated name, an exact name wins
+// over a fuzzy match and a name matching several changesets is refused.
+func findChangeset(ctx *context.Context, name string) (changeset.Changeset, error) {
+	cc, err := readChangesets(ctx)
+	if err != nil {
+		return changeset.Changeset{}, err
+	}
+
+	names := make([]string, len(cc))
+	for i, c := range cc {
+		if c.Name() == name {
+			return c, nil
+		}
+
+		names[i] = c.Name()
+	}
+
+	matches := fuzzy.Find(name, names)
+	if len(matches) == 0 {
+		return changeset.Changeset{}, errors.Wrap(ErrChangesetNotFound, name)
+	}
+
+	if len(matches) > 1 {
+		found := make([]string, len(matches))
+		for i, m := range matches {
+			found[i] = m.Str
+		}
+
+		return changeset.Changeset{}, errors.Wrapf(ErrChangesetAmbiguous, "%s matches %s", name, strings.Join(found, ", "))
+	}
+
+	return cc[matches[0].Index], nil
+}
@@ -0,0 +1,183 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"versioner/internal/changelog"
+	"versioner/internal/changes"
+	"versioner/internal/changeset"
+	"versioner/internal/config"
+	"versioner/internal/context"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+	"github.com/pkg/errors"
+)
+
+var ErrChangesetMissing = errors.New("source files changed but no changeset was added, run `versioner add`")
+
+type Check struct {
+	Changeset CheckChangeset `cmd:"" help:"Fails when source files changed since the base branch without adding a changeset"`
+}
+
+type CheckChangeset struct {
+	Markdown bool `help:"Print a markdown summary suitable for a pull request comment"`
+}
+
+func (c CheckChangeset) Run(ctx *context.Context) error {
+	conf, err := config.Read(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	files, err := changes.SinceBase(ctx.Repo(), conf.BaseBranch)
+	if err != nil {
+		return err
+	}
+
+	// files of other projects in the repository need no changeset here
+	files = ownFiles(ctx.Wd(), changes.Within(files, ctx.Dir()))
+
+	source := []string{}
+	ignored := 0
+	added := changeset.Changesets{}
+
+	for _, f := range files {
+		switch {
+		case strings.HasPrefix(f.Path, config.Dir+"/"):
+			if f.Action != merkletrie.Insert || path.Dir(f.Path) != config.Dir || path.Ext(f.Path) != ".md" {
+				continue
+			}
+
+			cs, err := changesetAt(ctx.Repo(), f.Hash, f.Path)
+			if err != nil {
+				return err
+			}
+
+			added = append(added, cs)
+		case f.Path == changelog.File(conf):
+			continue
+		case changes.Ignored(f.Path, conf.Ignore):
+			ignored++
+		default:
+			source = append(source, f.Path)
+		}
+	}
+
+	err = nil
+	if len(source) > 0 && len(added) == 0 {
+		err = ErrChangesetMissing
+	}
+
+	if c.Markdown {
+		fmt.Print(checkMarkdown(conf.BaseBranch, source, added, err))
+		return err
+	}
+
+	fmt.Printf("base branch: %s\n", conf.BaseBranch)
+	fmt.Printf("changed source files: %d (%d ignored)\n", len(source), ignored)
+
+	for _, f := range source {
+		fmt.Printf("  %s\n", f)
+	}
+
+	fmt.Printf("changesets added: %d\n", len(added))
+
+	for _, cs := range added {
+		fmt.Printf("  %s: %s\n", cs.Name(), cs.Summary)
+	}
+
+	if err != nil && os.Getenv("GITHUB_ACTIONS") == "true" {
+		fmt.Printf("::error title=Missing changeset::%s\n", err)
+	}
+
+	return err
+}
+
+func checkMarkdown(base string, source []string, added changeset.Changesets, err error) string {
+	var sb strings.Builder
+
+	sb.WriteString("### Changeset check\n\n")
+
+	switch {
+	case err != nil:
+		sb.WriteString(fmt.Sprintf(":x: %d source files changed since `%s` but no changeset was added. Run `versioner add` and commit the changeset.\n", len(source), base))
+	case len(added) == 0:
+		sb.WriteString(fmt.Sprintf(":white_check_mark: No source files changed since `%s`, no changeset needed.\n", base))
+	default:
+		sb.WriteString(fmt.Sprintf(":white_check_mark: %d changesets added since `%s`.\n", len(added), base))
+	}
+
+	if len(added) > 0 {
+		sb.WriteString("\n| Changeset | Type | Breaking | Summary |\n|---|---|---|---|\n")
+
+		for _, cs := range added {
+			breaking := ""
+			if cs.Breaking {
+				breaking = "yes"
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", tableCell(cs.Name()), tableCell(cs.Type), breaking, tableCell(cs.Summary)))
+		}
+	}
+
+	return sb.String()
+}
+
+// tableCell escapes the pipes that would end a cell of a markdown table.
+func tableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// ownFiles leaves out the files of projects nested in the project at wd,
+// which have a config of their own.
+func ownFiles(wd string, files []changes.File) []changes.File {
+	nested := map[string]bool{}
+
+	isNested := func(dir string) bool {
+		if _, ok := nested[dir]; !ok {
+			fi, err := os.Stat(path.Join(wd, dir, config.Dir))
+			nested[dir] = err == nil && fi.IsDir()
+		}
+
+		return nested[dir]
+	}
+
+	own := []changes.File{}
+
+	for _, f := range files {
+		inNested := false
+		for dir := path.Dir(f.Path); dir != "." && !inNested; dir = path.Dir(dir) {
+			inNested = isNested(dir)
+		}
+
+		if !inNested {
+			own = append(own, f)
+		}
+	}
+
+	return own
+}
+
+func changesetAt(repo *git.Repository, hash plumbing.Hash, name string) (changeset.Changeset, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return changeset.Changeset{}, err
+	}
+
+	r, err := blob.Reader()
+	if err != nil {
+		return changeset.Changeset{}, err
+	}
+	defer r.Close()
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return changeset.Changeset{}, err
+	}
+
+	return changeset.Parse(string(b), name)
+}
@@ -0,0 +1,18 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"versioner/internal/changeset"
+)
+
+func TestCheckMarkdownEscapesPipes(t *testing.T) {
+	added := changeset.Changesets{{Type: "feat", Summary: "Support a | b in filters"}}
+
+	got := checkMarkdown("main", nil, added, nil)
+
+	want := `| feat |  | Support a \| b in filters |`
+	if !strings.Contains(got, want) {
+		t.Errorf("table row is not escaped:\n%s", got)
+	}
+}
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"versioner/internal/config"
+	"versioner/internal/context"
+)
+
+type Config struct {
+	Show    ConfigShow    `cmd:"" help:"Prints the config merged from defaults, user and repo config, environment and flags"`
+	Get     ConfigGet     `cmd:"" help:"Prints the value of a config key"`
+	Set     ConfigSet     `cmd:"" help:"Sets a config key in the repo config, keeping the order, comments and indent of the file"`
+	Unset   ConfigUnset   `cmd:"" help:"Removes a config key from the repo config, keeping the order, comments and indent of the file"`
+	Schema  ConfigSchema  `cmd:"" help:"Prints the JSON Schema of the config"`
+	Migrate ConfigMigrate `cmd:"" help:"Upgrades the config to the latest schema version"`
+}
+
+type ConfigShow struct {
+	Origin bool `help:"Print each value with the layer it comes from"`
+}
+
+func (s ConfigShow) Run(ctx *context.Context) error {
+	conf, origins, err := config.ReadWithOrigins(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	// values set to false or empty by a layer are shown as well
+	values, err := config.Flatten(conf, origins)
+	if err != nil {
+		return err
+	}
+
+	if !s.Origin {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(config.Unflatten(values))
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tORIGIN")
+
+	for _, k := range keys {
+		b, err := json.Marshal(values[k])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", k, b, origins[k])
+	}
+
+	return w.Flush()
+}
+
+type ConfigGet struct {
+	Key string `arg:"" help:"Dotted key, like theme.accent"`
+}
+
+// Run prints strings as they are, so they can be used by scripts, and any
+// other value as JSON.
+func (g ConfigGet) Run(ctx *context.Context) error {
+	conf, err := config.Read(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	v, err := config.Get(conf, g.Key)
+	if err != nil {
+		return err
+	}
+
+	if s, ok := v.(string); ok {
+		fmt.Println(s)
+		return nil
+	}
+
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(b))
+
+	return nil
+}
+
+type ConfigSet struct {
+	Key   string `arg:"" help:"Dotted key, like theme.accent"`
+	Value string `arg:"" help:"Value of the key, lists of strings are comma separated and objects are JSON"`
+}
+
+func (s ConfigSet) Run(ctx *context.Context) error {
+	return config.SetValue(ctx.Wd(), s.Key, s.Value)
+}
+
+type ConfigUnset struct {
+	Key string `arg:"" help:"Dotted key, like theme.accent"`
+}
+
+func (u ConfigUnset) Run(ctx *context.Context) error {
+	return config.Unset(ctx.Wd(), u.Key)
+}
+
+type ConfigSchema struct{}
+
+func (s ConfigSchema) Run(ctx *context.Context) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(config.Schema())
+}
+
+type ConfigMigrate struct {
+	DryRun bool `help:"Print the migrations without writing anything"`
+}
+
+func (m ConfigMigrate) Run(ctx *context.Context) error {
+	from, steps, err := config.Migrate(ctx.Wd(), m.DryRun)
+	if err != nil {
+		return err
+	}
+
+	if len(steps) == 0 {
+		fmt.Printf("config is up to date with schema version %d\n", from)
+		return nil
+	}
+
+	for _, s := range steps {
+		fmt.Println(s)
+	}
+
+	if m.DryRun {
+		fmt.Printf("would migrate config from schema version %d to %d\n", from, config.LatestSchemaVersion)
+		return nil
+	}
+
+	fmt.Printf("migrated config from schema version %d to %d\n", from, config.LatestSchemaVersion)
+
+	return nil
+}
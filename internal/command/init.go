@@ -1,77 +1,357 @@
 package command
 
 import (
-	"encoding/json"
+	"fmt"
 	"os"
 	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"versioner/internal/changelog"
+	"versioner/internal/changeset"
 	"versioner/internal/config"
 	"versioner/internal/context"
+	"versioner/internal/detect"
+	"versioner/internal/tags"
+	"versioner/internal/tui"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/pkg/errors"
 )
 
-type Init struct{}
+const (
+	commitNone  = "none"
+	commitNew   = "commit"
+	commitAmend = "amend"
+)
+
+var ErrInitAborted = errors.New("init was aborted, nothing was written")
+
+// changelogCandidates are the files looked for when detecting the changelog.
+var changelogCandidates = []string{changelog.FileName, "CHANGES.md", "HISTORY.md", "docs/CHANGELOG.md"}
+
+type Init struct {
+	BaseBranch  string   `help:"Branch changes are merged into, detected from origin/HEAD when empty"`
+	TagTemplate string   `help:"Names the version tags, {version} is replaced by the version, detected from the existing tags when empty"`
+	Commit      string   `help:"What to do with the changes of a release: none, commit or amend"`
+	Changelog   string   `help:"Path of the changelog, detected when empty"`
+	Type        []string `help:"Adds a custom type as type:Title[:level], type! lets it be breaking"`
+	Format      string   `help:"Format of the config file: json, yaml or toml" enum:"json,yaml,toml" default:"json"`
+	Yes         bool     `short:"y" help:"Use the flags and detected values without asking, the default without a terminal"`
+}
 
 func (i Init) Run(ctx *context.Context) error {
 	if err := config.Ensure(ctx.Wd()); err == nil {
 		return config.AlreadyInitialized
 	}
 
-	configPath := path.Join(ctx.Wd(), config.Dir)
-	if err := os.MkdirAll(configPath, os.ModePerm); err != nil {
-		return err
-	}
-
-	configFilePath := path.Join(configPath, config.FileName)
-
-	branch, err := i.getBaseBranch(ctx.Repo())
+	conf, options, err := i.detect(ctx)
 	if err != nil {
 		return err
 	}
 
-	config := config.Configuration{
-		BaseBranch: branch,
-		Ignore:     []string{},
+	if !i.Yes && !tui.Plain() {
+		var abort bool
+		if conf, abort, err = tui.NewInitProgram(conf, options); err != nil {
+			return err
+		}
+
+		if abort {
+			return ErrInitAborted
+		}
 	}
 
-	var b []byte
-	if b, err = json.MarshalIndent(&config, "", "  "); err != nil {
+	if err = changeset.Register(conf.Types); err != nil {
 		return err
 	}
 
-	if err = os.WriteFile(configFilePath, b, os.ModePerm); err != nil {
+	if err = config.Create(ctx.Wd(), i.Format, conf); err != nil {
 		return err
 	}
 
+	i.hintImport(ctx.Wd(), conf)
+
 	return nil
 }
 
-func (i Init) getBaseBranch(repo *git.Repository) (string, error) {
-	conf, err := repo.Config()
+// detect seeds the config from the flags, the repository and an existing
+// changelog, the options are what the wizard offers next to them.
+func (i Init) detect(ctx *context.Context) (config.Configuration, tui.InitOptions, error) {
+	conf := config.Configuration{Ignore: []string{}}
+	options := tui.InitOptions{}
+
+	var err error
+
+	if options.Branches, err = i.getBaseBranches(ctx.Repo()); err != nil {
+		return conf, options, err
+	}
+
+	conf.BaseBranch = i.BaseBranch
+	if len(conf.BaseBranch) == 0 && len(options.Branches) > 0 {
+		conf.BaseBranch = options.Branches[0]
+	}
+	if len(conf.BaseBranch) > 0 {
+		options.Branches = prepend(conf.BaseBranch, options.Branches)
+	}
+
+	detected, err := i.getTagTemplate(ctx.Repo())
 	if err != nil {
-		return "", err
+		return conf, options, err
+	}
+
+	conf.TagTemplate = i.TagTemplate
+	if len(conf.TagTemplate) == 0 {
+		conf.TagTemplate = detected
+	}
+	if conf.TagTemplate == config.VersionPlaceholder {
+		conf.TagTemplate = ""
+	}
+	if len(conf.TagTemplate) > 0 && !strings.Contains(conf.TagTemplate, config.VersionPlaceholder) {
+		return conf, options, errors.Errorf("tag template %q does not contain %s", conf.TagTemplate, config.VersionPlaceholder)
+	}
+
+	tmpl := conf.TagTemplate
+	if len(tmpl) == 0 {
+		tmpl = config.VersionPlaceholder
 	}
+	options.Templates = prepend(tmpl, []string{config.VersionPlaceholder, "v" + config.VersionPlaceholder})
 
-	main := conf.Branches["main"]
-	master := conf.Branches["master"]
+	switch i.Commit {
+	case "", commitNone:
+	case commitNew:
+		conf.Commit = true
+	case commitAmend:
+		conf.Commit, conf.AmendCommit = true, true
+	default:
+		return conf, options, errors.Errorf("unknown commit behaviour %q, use none, commit or amend", i.Commit)
+	}
+
+	found := i.getChangelogs(ctx.Wd())
 
-	if main != nil {
-		return "main", nil
+	conf.Changelog = i.Changelog
+	if len(conf.Changelog) == 0 && len(found) > 0 {
+		conf.Changelog = found[0]
 	}
+	if conf.Changelog == changelog.FileName {
+		conf.Changelog = ""
+	}
+	options.Changelogs = prepend(changelog.File(conf), append(found, changelog.FileName))
+
+	for _, t := range i.Type {
+		ct, err := parseTypeFlag(t)
+		if err != nil {
+			return conf, options, err
+		}
+
+		conf.Types = append(conf.Types, ct)
+	}
+
+	// types given as flags are not offered again
+	for _, t := range i.getChangelogTypes(path.Join(ctx.Wd(), changelog.File(conf))) {
+		given := false
+		for _, ct := range conf.Types {
+			given = given || ct.Type == t.Type || ct.Title == t.Title
+		}
 
-	if master != nil {
-		return "master", nil
+		if !given {
+			options.Types = append(options.Types, t)
+		}
 	}
 
+	// without the wizard the types found in the changelog are added as well
+	if i.Yes || tui.Plain() {
+		conf.Types = append(conf.Types, options.Types...)
+	}
+
+	return conf, options, nil
+}
+
+// getBaseBranches lists the branches that could be the base branch, the
+// branch origin/HEAD points at first, then main, master and the current
+// branch, then the rest by name.
+func (i Init) getBaseBranches(repo *git.Repository) ([]string, error) {
 	branches := []string{}
 
-	for branch := range conf.Branches {
-		branches = append(branches, branch)
+	if ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName(defaultRemote), false); err == nil {
+		if target := ref.Target(); target.IsRemote() {
+			branches = append(branches, strings.TrimPrefix(target.Short(), defaultRemote+"/"))
+		}
 	}
 
-	if len(branches) > 0 {
-		return branches[0], nil
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	defer refs.Close()
+
+	others := []string{}
+
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		switch {
+		case ref.Name().IsBranch():
+			others = append(others, ref.Name().Short())
+		case ref.Name().IsRemote() && strings.HasPrefix(ref.Name().Short(), defaultRemote+"/"):
+			if name := strings.TrimPrefix(ref.Name().Short(), defaultRemote+"/"); name != "HEAD" {
+				others = append(others, name)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// a repository without commits only knows the branch HEAD points at
+	current := ""
+	if head, err := repo.Reference(plumbing.HEAD, false); err == nil && head.Target().IsBranch() {
+		current = head.Target().Short()
+		others = append(others, current)
+	}
+
+	rank := func(branch string) int {
+		switch branch {
+		case "main":
+			return 0
+		case "master":
+			return 1
+		case current:
+			return 2
+		}
+
+		return 3
+	}
+
+	sort.SliceStable(others, func(a, b int) bool {
+		return rank(others[a]) < rank(others[b]) || rank(others[a]) == rank(others[b]) && others[a] < others[b]
+	})
+
+	return unique(append(branches, others...)), nil
+}
+
+// getTagTemplate guesses the tag template from the latest version tag.
+func (i Init) getTagTemplate(repo *git.Repository) (string, error) {
+	idx, err := tags.New(repo, "")
+	if err != nil {
+		return "", err
+	}
+
+	vv := idx.Versions()
+	if len(vv) > 0 && strings.HasPrefix(vv[len(vv)-1].Name, "v") {
+		return "v" + config.VersionPlaceholder, nil
 	}
 
 	return "", nil
 }
+
+// getChangelogs lists the changelogs that exist in the project.
+func (i Init) getChangelogs(wd string) []string {
+	found := []string{}
+
+	for _, c := range changelogCandidates {
+		if _, err := os.Stat(path.Join(wd, c)); err == nil {
+			found = append(found, c)
+		}
+	}
+
+	return found
+}
+
+// getChangelogTypes offers the sections of an existing changelog that no type
+// matches as custom types.
+func (i Init) getChangelogTypes(file string) []config.Type {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	types := []config.Type{}
+
+	for _, title := range changelog.UnknownSections(string(b)) {
+		key := strings.ToLower(strings.Join(strings.FieldsFunc(title, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}), "-"))
+
+		// the key made from the title can still be taken, like "Docs" by docs
+		if _, err := (changeset.Changeset{Type: key}).ConventionalType(); len(key) == 0 || err == nil {
+			continue
+		}
+
+		types = append(types, config.Type{Type: key, Title: title, Level: changeset.Patch})
+	}
+
+	return types
+}
+
+// hintImport points at changelog import when the changelog is not in the
+// versioner format yet.
+func (i Init) hintImport(wd string, conf config.Configuration) {
+	b, err := os.ReadFile(path.Join(wd, changelog.File(conf)))
+	if err != nil {
+		return
+	}
+
+	project, err := detect.Run(wd)
+	if err != nil {
+		return
+	}
+
+	if _, err = changelog.ParseMarkdown(project.Name, string(b)); err != nil {
+		fmt.Printf("%s is not in the versioner format, run `versioner changelog import` to convert it\n", changelog.File(conf))
+	}
+}
+
+// parseTypeFlag reads a custom type written as type:Title[:level], a type
+// ending in ! can be breaking.
+func parseTypeFlag(flag string) (config.Type, error) {
+	parts := strings.SplitN(flag, ":", 3)
+	if len(parts) < 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return config.Type{}, errors.Errorf("type %q must be written as type:Title[:level]", flag)
+	}
+
+	t := config.Type{
+		Type:  strings.TrimSuffix(parts[0], "!"),
+		Title: parts[1],
+		Level: changeset.Patch,
+	}
+	t.CanBeBreaking = t.Type != parts[0]
+
+	if len(parts) == 3 {
+		level, ok := changeset.ParseLevel(parts[2])
+		if !ok {
+			return config.Type{}, errors.Errorf("type %q has unknown level %q, use major, minor, patch or none", flag, parts[2])
+		}
+
+		t.Level = level
+	}
+
+	return t, nil
+}
+
+// prepend puts s in front of ss, removing it from where it was.
+func prepend(s string, ss []string) []string {
+	out := []string{s}
+
+	for _, o := range ss {
+		if o != s {
+			out = append(out, o)
+		}
+	}
+
+	return out
+}
+
+func unique(ss []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+
+	for _, s := range ss {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+
+	return out
+}
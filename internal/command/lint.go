@@ -0,0 +1,32 @@
+package command
+
+import (
+	"os"
+	"versioner/internal/context"
+	"versioner/internal/lint"
+
+	"github.com/pkg/errors"
+)
+
+var ErrLintFailed = errors.New("lint found errors")
+
+type Lint struct {
+	Format string `help:"Output format of the diagnostics" enum:"text,json,sarif" default:"text"`
+}
+
+func (l Lint) Run(ctx *context.Context) error {
+	dd, err := lint.Run(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	if err = lint.Write(os.Stdout, l.Format, dd); err != nil {
+		return err
+	}
+
+	if dd.HasErrors() {
+		return ErrLintFailed
+	}
+
+	return nil
+}
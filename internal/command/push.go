@@ -0,0 +1,127 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"versioner/internal/config"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultRemote    = "origin"
+	tokenEnv         = "VERSIONER_GIT_TOKEN"
+	usernameEnv      = "VERSIONER_GIT_USERNAME"
+	sshPassphraseEnv = "VERSIONER_SSH_PASSPHRASE"
+)
+
+var ErrPushRejected = errors.New("push was rejected by the remote and the tag was removed, pull and tag again")
+
+// pushRelease pushes the branch HEAD is on together with the tag, a detached
+// HEAD only pushes the tag.
+func pushRelease(repo *git.Repository, conf config.Configuration, remoteName, tag string) error {
+	if len(remoteName) == 0 {
+		remoteName = conf.Remote
+	}
+
+	if len(remoteName) == 0 {
+		remoteName = defaultRemote
+	}
+
+	remote, err := repo.Remote(remoteName)
+	if err != nil {
+		return errors.Wrapf(err, "could not find remote %s", remoteName)
+	}
+
+	h, err := repo.Head()
+	if err != nil {
+		return err
+	}
+
+	tagRef := plumbing.NewTagReferenceName(tag)
+	refSpecs := []gitconfig.RefSpec{
+		gitconfig.RefSpec(fmt.Sprintf("%s:%s", tagRef, tagRef)),
+	}
+
+	if h.Name().IsBranch() {
+		refSpecs = append([]gitconfig.RefSpec{
+			gitconfig.RefSpec(fmt.Sprintf("%s:%s", h.Name(), h.Name())),
+		}, refSpecs...)
+	}
+
+	var auth transport.AuthMethod
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		if auth, err = pushAuth(urls[0], conf); err != nil {
+			return err
+		}
+	}
+
+	err = repo.Push(&git.PushOptions{
+		RemoteName: remoteName,
+		RefSpecs:   refSpecs,
+		Auth:       auth,
+		// the tag is removed when the push fails, so it must not land alone
+		Atomic: true,
+	})
+
+	if errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil
+	}
+
+	if errors.Is(err, git.ErrForceNeeded) || err != nil && isRejection(err) {
+		return errors.Wrap(ErrPushRejected, err.Error())
+	}
+
+	return errors.Wrapf(err, "could not push to %s", remoteName)
+}
+
+// pushAuth picks the authentication for the remote, a token from the
+// environment for http remotes and a configured key or the SSH agent for ssh.
+func pushAuth(url string, conf config.Configuration) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, err
+	}
+
+	switch endpoint.Protocol {
+	case "http", "https":
+		token := os.Getenv(tokenEnv)
+		if len(token) == 0 {
+			return nil, nil
+		}
+
+		username := os.Getenv(usernameEnv)
+		if len(username) == 0 {
+			username = "git"
+		}
+
+		return &http.BasicAuth{Username: username, Password: token}, nil
+	case "ssh":
+		user := endpoint.User
+		if len(user) == 0 {
+			user = "git"
+		}
+
+		if len(conf.SSHKey) > 0 {
+			auth, err := ssh.NewPublicKeysFromFile(user, conf.SSHKey, os.Getenv(sshPassphraseEnv))
+			return auth, errors.Wrap(err, "could not read ssh key")
+		}
+
+		auth, err := ssh.NewSSHAgentAuth(user)
+		return auth, errors.Wrap(err, "could not connect to ssh agent")
+	}
+
+	return nil, nil
+}
+
+func isRejection(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "non-fast-forward") || strings.Contains(msg, "command error on")
+}
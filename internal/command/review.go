@@ -0,0 +1,48 @@
+package command
+
+import (
+	"versioner/internal/changeset"
+	"versioner/internal/config"
+	"versioner/internal/context"
+	"versioner/internal/tags"
+	"versioner/internal/tui"
+
+	"github.com/pkg/errors"
+)
+
+var ErrReviewNeedsTerminal = errors.New("review needs a terminal, use changeset list, edit and rm instead")
+
+type Review struct{}
+
+func (r Review) Run(ctx *context.Context) error {
+	conf, err := config.Read(ctx.Wd())
+	if err != nil {
+		return err
+	}
+
+	if tui.Plain() {
+		return ErrReviewNeedsTerminal
+	}
+
+	cc, err := changeset.ParseChangesets(ctx.Wd())
+	if err != nil {
+		return errors.Wrap(err, "could not read changesets")
+	}
+
+	idx, err := tags.New(ctx.Repo(), conf.TagTemplate)
+	if err != nil {
+		return err
+	}
+
+	latest, err := idx.Latest()
+	if err != nil {
+		return err
+	}
+
+	release, err := tui.NewReviewProgram(ctx.Wd(), cc, *latest.Version)
+	if err != nil || !release {
+		return err
+	}
+
+	return Version{}.Run(ctx)
+}
@@ -1,25 +1,52 @@
 package command
 
 import (
-	"errors"
-	"strings"
+	"fmt"
+	"os"
+	"time"
+	"versioner/internal/changelog"
 	"versioner/internal/config"
 	"versioner/internal/context"
+	"versioner/internal/detect"
+	"versioner/internal/tags"
 
 	"github.com/Masterminds/semver"
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+	"github.com/tcnksm/go-gitconfig"
 )
 
+const signPassphraseEnv = "VERSIONER_SIGN_PASSPHRASE"
+
 var (
-	NoVersionAvailable = errors.New("there is no version available for tagging")
-	TagAlreadyExist    = errors.New("tag already exist")
+	NoVersionAvailable  = errors.New("there is no version available for tagging")
+	ErrNoSignKey        = errors.New("keyring does not contain a private key to sign with")
+	ErrNoKeyring        = errors.New("no keyring configured, set signKey in config or use --keyring")
+	ErrTagsUnverified   = errors.New("one or more version tags could not be verified")
+	ErrDirtyWorktree    = errors.New("worktree has uncommitted changes, commit them or use --allow-dirty")
+	ErrMissingEntry     = errors.New("HEAD has no changelog entry for the version, run version and commit or use --skip-changelog-check")
+	ErrNotReleaseCommit = errors.New("HEAD is not the commit that released the version, check out the release commit or use --skip-changelog-check")
+	ErrVersionLower     = errors.New("version is lower than the latest version tag, use --allow-lower to tag anyway")
 )
 
-type Tag struct{}
+type Tag struct {
+	Create TagCreate `cmd:"" default:"withargs" help:"Creates a new tag of the current version"`
+	Verify TagVerify `cmd:"" help:"Verifies the signatures of the existing version tags"`
+}
+
+type TagCreate struct {
+	SignKey            string `help:"Armored GPG keyring used to sign the tag, overrides signKey in config" type:"path"`
+	Push               bool   `help:"Push the release branch and the tag, same as push in config"`
+	Remote             string `help:"Remote to push to, overrides remote in config"`
+	AllowDirty         bool   `help:"Tag even though the worktree has uncommitted changes"`
+	SkipChangelogCheck bool   `help:"Tag even though HEAD has no changelog entry for the version"`
+	AllowLower         bool   `help:"Tag even though the version is lower than the latest version tag"`
+}
 
-func (t Tag) Run(ctx *context.Context) error {
+func (t TagCreate) Run(ctx *context.Context) error {
 	conf, err := config.Read(ctx.Wd())
 	if err != nil {
 		return err
@@ -29,87 +56,294 @@ func (t Tag) Run(ctx *context.Context) error {
 		return nil
 	}
 
-	if err = t.tagExists(conf.NextVersion, ctx.Repo()); err != nil {
+	h, err := ctx.Repo().Head()
+	if err != nil {
 		return err
 	}
 
-	h, err := ctx.Repo().Head()
+	idx, err := tags.New(ctx.Repo(), conf.TagTemplate)
 	if err != nil {
 		return err
 	}
 
-	if _, err = ctx.Repo().CreateTag(conf.NextVersion, h.Hash(), &git.CreateTagOptions{Message: conf.NextVersion}); err != nil {
+	name := conf.TagName(conf.NextVersion)
+
+	if err = idx.Check(name, h.Hash()); err != nil {
+		return err
+	}
+
+	if err = t.preflight(ctx, idx, conf, h.Hash()); err != nil {
 		return err
 	}
 
-	return nil
-}
+	msg, err := releaseNotes(ctx.Wd(), changelog.File(conf), conf.NextVersion)
+	if err != nil {
+		return err
+	}
 
-func (t Tag) tagExists(tag string, r *git.Repository) error {
-	tags, err := r.TagObjects()
+	tagger, err := gitSignature()
 	if err != nil {
 		return err
 	}
 
-	res := false
-	tags.ForEach(func(t *object.Tag) error {
-		if t.Name == tag {
-			res = true
-			return nil
+	opts := &git.CreateTagOptions{
+		Tagger:  tagger,
+		Message: msg,
+	}
+
+	keyring := conf.SignKey
+	if len(t.SignKey) > 0 {
+		keyring = t.SignKey
+	}
+
+	if len(keyring) > 0 {
+		if opts.SignKey, err = loadSignKey(keyring); err != nil {
+			return err
 		}
+	}
+
+	if _, err = ctx.Repo().CreateTag(name, h.Hash(), opts); err != nil {
+		return err
+	}
+
+	if !t.Push && !conf.Push {
 		return nil
-	})
+	}
+
+	if err = pushRelease(ctx.Repo(), conf, t.Remote, name); err != nil {
+		// the tag is created again once the branch is up to date
+		if derr := ctx.Repo().DeleteTag(name); derr != nil {
+			return errors.Wrapf(err, "could not remove tag %s after the push failed: %s", name, derr)
+		}
 
-	if res {
-		return TagAlreadyExist
+		return err
 	}
 
 	return nil
 }
 
-func findLatestTag(repo *git.Repository, tagToFind string) (string, plumbing.Hash, *semver.Version, error) {
-	tagList := make(map[plumbing.Hash]string)
+// preflight makes sure the tag ends up on the release commit, each check can
+// be turned off with its own flag.
+func (t TagCreate) preflight(ctx *context.Context, idx tags.Index, conf config.Configuration, head plumbing.Hash) error {
+	version := conf.NextVersion
+
+	if !t.AllowDirty {
+		w, err := ctx.Repo().Worktree()
+		if err != nil {
+			return err
+		}
 
-	tags, err := repo.Tags()
+		status, err := w.Status()
+		if err != nil {
+			return err
+		}
+
+		if !status.IsClean() {
+			return ErrDirtyWorktree
+		}
+	}
+
+	if !t.SkipChangelogCheck {
+		c, err := changelogAt(ctx, changelog.File(conf), head)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := c.Entry(version); !ok {
+			return errors.Wrap(ErrMissingEntry, version)
+		}
+
+		commit, err := ctx.Repo().CommitObject(head)
+		if err != nil {
+			return err
+		}
+
+		if commit.NumParents() > 0 {
+			if c, err = changelogAt(ctx, changelog.File(conf), commit.ParentHashes[0]); err != nil {
+				return err
+			}
+
+			if _, ok := c.Entry(version); ok {
+				return errors.Wrap(ErrNotReleaseCommit, version)
+			}
+		}
+	}
+
+	if !t.AllowLower {
+		next, err := semver.NewVersion(version)
+		if err != nil {
+			return errors.Wrap(err, "could not parse next version")
+		}
+
+		vv := idx.Versions()
+		if len(vv) > 0 && next.LessThan(vv[len(vv)-1].Version) {
+			return errors.Wrap(ErrVersionLower, fmt.Sprintf("%s < %s", version, vv[len(vv)-1].Name))
+		}
+	}
+
+	return nil
+}
+
+type TagVerify struct {
+	Keyring string `help:"Armored GPG keyring with the public keys to verify against, defaults to signKey in config" type:"path"`
+}
+
+func (t TagVerify) Run(ctx *context.Context) error {
+	conf, err := config.Read(ctx.Wd())
 	if err != nil {
-		return "", plumbing.ZeroHash, nil, err
+		return err
 	}
 
-	for ref, err := tags.Next(); err == nil; ref, err = tags.Next() {
-		tagName := ref.Name().Short()
-		if !strings.Contains(tagName, tagToFind) {
+	keyring := conf.SignKey
+	if len(t.Keyring) > 0 {
+		keyring = t.Keyring
+	}
+
+	if len(keyring) == 0 {
+		return ErrNoKeyring
+	}
+
+	b, err := os.ReadFile(keyring)
+	if err != nil {
+		return errors.Wrap(err, "could not read keyring")
+	}
+
+	idx, err := tags.New(ctx.Repo(), conf.TagTemplate)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+
+	for _, vt := range idx.Versions() {
+		if !vt.Annotated {
+			failed = true
+			fmt.Printf("%s: lightweight tag, not signed\n", vt.Name)
+			continue
+		}
+
+		obj, err := ctx.Repo().TagObject(vt.Ref)
+		if err != nil {
+			return err
+		}
+
+		if len(obj.PGPSignature) == 0 {
+			failed = true
+			fmt.Printf("%s: not signed\n", vt.Name)
 			continue
 		}
 
-		obj, err := repo.TagObject(ref.Hash())
-		if err == nil {
-			tagList[obj.Target] = tagName
-		} else {
-			tagList[ref.Hash()] = tagName
+		entity, err := obj.Verify(string(b))
+		if err != nil {
+			failed = true
+			fmt.Printf("%s: bad signature: %s\n", vt.Name, err)
+			continue
 		}
+
+		signer := entity.PrimaryKey.KeyIdString()
+		if id := entity.PrimaryIdentity(); id != nil {
+			signer = id.Name
+		}
+
+		fmt.Printf("%s: good signature from %s\n", vt.Name, signer)
+	}
+
+	if failed {
+		return ErrTagsUnverified
 	}
-	tags.Close()
 
-	iter, err := repo.Log(&git.LogOptions{})
+	return nil
+}
+
+// releaseNotes returns the changelog entry of the version, falling back to
+// the version itself when the changelog does not have it.
+func releaseNotes(wd, file, version string) (string, error) {
+	c, err := changelog.Parse(wd, file)
 	if err != nil {
-		return "", plumbing.ZeroHash, nil, err
+		return "", err
 	}
-	defer iter.Close()
 
-	for ref, err := iter.Next(); err == nil; ref, err = iter.Next() {
-		tag, found := tagList[ref.Hash]
-		if found {
-			version, err := semver.NewVersion(tag)
-			if err == nil {
-				return tag, ref.Hash, version, nil
-			}
-		}
+	if e, ok := c.Entry(version); ok {
+		return e.Markdown(), nil
+	}
+
+	return version, nil
+}
+
+// changelogAt parses the changelog file as it is committed in the commit.
+func changelogAt(ctx *context.Context, file string, hash plumbing.Hash) (changelog.Changelog, error) {
+	project, err := detect.Run(ctx.Wd())
+	if err != nil {
+		return changelog.Changelog{}, err
+	}
+
+	commit, err := ctx.Repo().CommitObject(hash)
+	if err != nil {
+		return changelog.Changelog{}, err
+	}
+
+	f, err := commit.File(ctx.RepoPath(file))
+	if errors.Is(err, object.ErrFileNotFound) {
+		return changelog.Changelog{Title: project.Name}, nil
+	}
+
+	if err != nil {
+		return changelog.Changelog{}, err
+	}
+
+	content, err := f.Contents()
+	if err != nil {
+		return changelog.Changelog{}, err
+	}
+
+	return changelog.ParseMarkdown(project.Name, content)
+}
+
+func gitSignature() (*object.Signature, error) {
+	name, err := gitconfig.Username()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read user.name from git config")
 	}
 
-	version, err := semver.NewVersion("0.0.0")
+	email, err := gitconfig.Email()
 	if err != nil {
-		return "", plumbing.ZeroHash, nil, err
+		return nil, errors.Wrap(err, "could not read user.email from git config")
+	}
+
+	return &object.Signature{
+		Name:  name,
+		Email: email,
+		When:  time.Now(),
+	}, nil
+}
+
+// loadSignKey reads the first private key in an armored keyring, decrypting it
+// with the passphrase from the environment when it is protected.
+func loadSignKey(keyring string) (*openpgp.Entity, error) {
+	f, err := os.Open(keyring)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read keyring")
+	}
+	defer f.Close()
+
+	entities, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read keyring")
+	}
+
+	for _, e := range entities {
+		if e.PrivateKey == nil {
+			continue
+		}
+
+		if e.PrivateKey.Encrypted {
+			if err = e.DecryptPrivateKeys([]byte(os.Getenv(signPassphraseEnv))); err != nil {
+				return nil, errors.Wrapf(err, "could not decrypt sign key, set the passphrase in %s", signPassphraseEnv)
+			}
+		}
+
+		return e, nil
 	}
 
-	return "", plumbing.ZeroHash, version, nil
+	return nil, ErrNoSignKey
 }
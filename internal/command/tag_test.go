@@ -0,0 +1,222 @@
+package command
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"versioner/internal/config"
+	"versioner/internal/context"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const releaseChangelog = `# example
+
+[//]: # entry
+## 0.1.1
+
+### Bug fixes
+
+Fix something
+`
+
+// releaseRepo makes a project whose HEAD is the release commit of 0.1.1, on
+// top of the commit pushed to a bare repository as origin.
+func releaseRepo(t *testing.T) (*git.Repository, context.Context, string) {
+	t.Helper()
+
+	gitHome(t)
+
+	bare := t.TempDir()
+	if _, err := git.PlainInit(bare, true); err != nil {
+		t.Fatal(err)
+	}
+
+	wd := t.TempDir()
+
+	repo, err := git.PlainInit(wd, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(wd, "go.mod"), "module example\n")
+	commitAll(t, repo, "Initial commit")
+
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: defaultRemote, URLs: []string{bare}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = repo.Push(&git.PushOptions{RemoteName: defaultRemote}); err != nil {
+		t.Fatal(err)
+	}
+
+	release(t, repo, wd)
+
+	return repo, context.New(repo, wd, wd), bare
+}
+
+// gitHome points the global git config at a temporary home with a user.
+func gitHome(t *testing.T) {
+	t.Helper()
+
+	home := t.TempDir()
+	gitConfig := filepath.Join(home, ".gitconfig")
+	writeFile(t, gitConfig, "[user]\n\tname = Tester\n\temail = tester@example.com\n")
+
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_GLOBAL", gitConfig)
+}
+
+// release commits the changelog entry and config of 0.1.1 like version does.
+func release(t *testing.T, repo *git.Repository, wd string) {
+	t.Helper()
+
+	if err := config.Create(wd, "json", config.Configuration{NextVersion: "0.1.1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(wd, "CHANGELOG.md"), releaseChangelog)
+	commitAll(t, repo, "New version")
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func commitAll(t *testing.T, repo *git.Repository, msg string) {
+	t.Helper()
+
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = w.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = w.Commit(msg, &git.CommitOptions{
+		Author: &object.Signature{Name: "Tester", Email: "tester@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// pushFromClone moves master on the remote ahead of the project.
+func pushFromClone(t *testing.T, bare string) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	clone, err := git.PlainClone(dir, false, &git.CloneOptions{URL: bare})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(dir, "other.go"), "package example\n")
+	commitAll(t, clone, "Other change")
+
+	if err = clone.Push(&git.PushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func remoteTag(t *testing.T, bare, name string) bool {
+	t.Helper()
+
+	remote, err := git.PlainOpen(bare)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = remote.Tag(name)
+	if err != nil && !errors.Is(err, git.ErrTagNotFound) {
+		t.Fatal(err)
+	}
+
+	return err == nil
+}
+
+func TestTagCreatePush(t *testing.T) {
+	repo, ctx, bare := releaseRepo(t)
+
+	if err := (TagCreate{Push: true}).Run(&ctx); err != nil {
+		t.Fatalf("tag --push: %v", err)
+	}
+
+	if !remoteTag(t, bare, "0.1.1") {
+		t.Error("tag 0.1.1 was not pushed")
+	}
+
+	if _, err := repo.Tag("0.1.1"); err != nil {
+		t.Errorf("local tag 0.1.1: %v", err)
+	}
+}
+
+func TestTagCreatePushRejected(t *testing.T) {
+	repo, ctx, bare := releaseRepo(t)
+	pushFromClone(t, bare)
+
+	err := (TagCreate{Push: true}).Run(&ctx)
+	if !errors.Is(err, ErrPushRejected) {
+		t.Fatalf("tag --push on a stale branch: got %v, want %v", err, ErrPushRejected)
+	}
+
+	if _, err = repo.Tag("0.1.1"); !errors.Is(err, git.ErrTagNotFound) {
+		t.Errorf("local tag 0.1.1 is left after the rejected push: %v", err)
+	}
+
+	if remoteTag(t, bare, "0.1.1") {
+		t.Error("tag 0.1.1 was pushed without the branch")
+	}
+}
+
+func TestTagCreatePushRetry(t *testing.T) {
+	repo, ctx, bare := releaseRepo(t)
+	pushFromClone(t, bare)
+
+	if err := (TagCreate{Push: true}).Run(&ctx); !errors.Is(err, ErrPushRejected) {
+		t.Fatalf("tag --push on a stale branch: got %v, want %v", err, ErrPushRejected)
+	}
+
+	// pull and release again on top of the change from the clone
+	if err := repo.Fetch(&git.FetchOptions{RemoteName: defaultRemote}); err != nil {
+		t.Fatal(err)
+	}
+
+	remoteMaster, err := repo.Reference(plumbing.NewRemoteReferenceName(defaultRemote, "master"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = w.Reset(&git.ResetOptions{Commit: remoteMaster.Hash(), Mode: git.HardReset}); err != nil {
+		t.Fatal(err)
+	}
+
+	release(t, repo, ctx.Wd())
+
+	if err = (TagCreate{Push: true}).Run(&ctx); err != nil {
+		t.Fatalf("tag --push after pulling: %v", err)
+	}
+
+	if !remoteTag(t, bare, "0.1.1") {
+		t.Error("tag 0.1.1 was not pushed on retry")
+	}
+}
@@ -1,24 +1,56 @@
 package command
 
 import (
+	"os"
+	"path"
+	"strings"
 	"versioner/internal/changelog"
 	"versioner/internal/changeset"
 	"versioner/internal/config"
 	"versioner/internal/context"
+	"versioner/internal/fsutil"
+	"versioner/internal/tags"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/pkg/errors"
 )
 
-type Version struct{}
+const defaultCommitMsg = "New version"
+
+var (
+	ErrNothingToUndo  = errors.New("there is no version to undo")
+	ErrAlreadyTagged  = errors.New("version is already tagged, use --force to undo anyway")
+	ErrUndoDirtyReset = errors.New("worktree has uncommitted changes, commit or stash them before undoing the release commit")
+	ErrUnknownArchive = errors.New("unknown archive mode, use directory, manifest or leave it empty")
+	ErrUnrecoverable  = errors.New("changesets were never committed and no undo record was found, nothing was undone")
+)
+
+type Version struct {
+	Undo  bool `help:"Undo the last version, restoring its changesets and removing its changelog entry"`
+	Force bool `help:"Undo even though the version is already tagged"`
+}
 
 func (v Version) Run(ctx *context.Context) error {
+	// parallel jobs releasing the same checkout would mix their changes
+	unlock, err := config.Lock(ctx.Wd())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	conf, err := config.Read(ctx.Wd())
 	if err != nil {
 		return err
 	}
 
-	w, err := ctx.Repo().Worktree()
+	if v.Undo {
+		return v.undo(ctx, conf)
+	}
+
+	w, err := releaseWorktree(ctx)
 	if err != nil {
 		return err
 	}
@@ -28,17 +60,37 @@ func (v Version) Run(ctx *context.Context) error {
 		return errors.Wrap(err, "could not read changesets")
 	}
 
-	_, _, curr, err := findLatestTag(ctx.Repo(), "")
+	idx, err := tags.New(ctx.Repo(), conf.TagTemplate)
 	if err != nil {
 		return err
 	}
 
-	entry, err := changelog.NewEntry(*curr, cc)
+	latest, err := idx.Latest()
 	if err != nil {
 		return err
 	}
 
-	c, err := changelog.Parse(ctx.Wd())
+	entry, err := changelog.NewEntry(*latest.Version, cc)
+	if err != nil {
+		return err
+	}
+
+	state, err := ctx.StateDir()
+	if err != nil {
+		return err
+	}
+
+	// the changesets may never have been committed, so undo needs a copy
+	record, err := changeset.NewUndoRecord(state, entry.Version, cc)
+	if err != nil {
+		return err
+	}
+
+	if err = record.Save(); err != nil {
+		return err
+	}
+
+	c, err := changelog.Parse(ctx.Wd(), changelog.File(conf))
 	if err != nil {
 		return err
 	}
@@ -49,32 +101,400 @@ func (v Version) Run(ctx *context.Context) error {
 		return err
 	}
 
-	conf.NextVersion = entry.Version
-	if err = config.Set(ctx.Wd(), conf); err != nil {
+	err = config.Update(ctx.Wd(), func(c *config.Configuration) {
+		c.NextVersion = entry.Version
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = v.consume(ctx.Wd(), conf, entry.Version, cc); err != nil {
+		return err
+	}
+
+	if !conf.Commit {
+		return nil
+	}
+
+	hash, err := commitRelease(ctx, w, commitMsg(conf), conf.AmendCommit)
+	if err != nil {
 		return err
 	}
 
-	if err = cc.Remove(); err != nil {
+	record.Commit, record.Amend = hash.String(), conf.AmendCommit
+
+	return record.Save()
+}
+
+// consume removes the released changesets from .versioner, archiving them
+// first when an archive mode is configured.
+func (v Version) consume(wd string, conf config.Configuration, version string, cc changeset.Changesets) error {
+	switch conf.Archive {
+	case config.ArchiveDirectory:
+		if err := cc.Archive(wd, version); err != nil {
+			return err
+		}
+
+		return changeset.PruneArchive(wd, conf.ArchiveRetention)
+	case config.ArchiveManifest:
+		m, err := changelog.ReadManifest(wd)
+		if err != nil {
+			return err
+		}
+
+		m.Add(changelog.NewRelease(version, cc))
+		m.Prune(conf.ArchiveRetention)
+
+		if err = m.Save(); err != nil {
+			return err
+		}
+	case "":
+	default:
+		return errors.Wrapf(ErrUnknownArchive, "%q", conf.Archive)
+	}
+
+	return cc.Remove()
+}
+
+// undo reverts the last run of version. A release commit made by versioner is
+// reset away, otherwise the changes are reverted file by file and an amended
+// commit is amended once more.
+func (v Version) undo(ctx *context.Context, conf config.Configuration) error {
+	version := conf.NextVersion
+	if len(version) == 0 {
+		return ErrNothingToUndo
+	}
+
+	idx, err := tags.New(ctx.Repo(), conf.TagTemplate)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := idx.Find(conf.TagName(version)); ok && !v.Force {
+		return errors.Wrap(ErrAlreadyTagged, version)
+	}
+
+	w, err := releaseWorktree(ctx)
+	if err != nil {
+		return err
+	}
+
+	head, err := headCommit(ctx)
+	if err != nil {
+		return err
+	}
+
+	state, err := ctx.StateDir()
+	if err != nil {
+		return err
+	}
+
+	record, recorded, err := changeset.ReadUndoRecord(state, version)
+	if err != nil {
+		return err
+	}
+
+	// only the commit versioner recorded as its own is reset or amended
+	made := recorded && head != nil && head.Hash.String() == record.Commit
+
+	if made && !record.Amend {
+		status, err := w.Status()
+		if err != nil {
+			return err
+		}
+
+		if !status.IsClean() {
+			return ErrUndoDirtyReset
+		}
+
+		if err = w.Reset(&git.ResetOptions{Commit: head.ParentHashes[0], Mode: git.HardReset}); err != nil {
+			return err
+		}
+
+		// changesets added without being committed are not in the parent
+		if err = record.Restore(ctx.Wd()); err != nil {
+			return err
+		}
+
+		return record.Remove()
+	}
+
+	inHead := false
+	if head != nil {
+		if inHead, err = releasedIn(ctx, changelog.File(conf), head, version); err != nil {
+			return err
+		}
+	}
+
+	// the changesets are still in HEAD unless the release was committed
+	base := head
+	if inHead {
+		if base, err = head.Parent(0); err != nil {
+			return err
+		}
+	}
+
+	if err = v.restore(ctx, conf, version, base, record, recorded); err != nil {
+		return err
+	}
+
+	c, err := changelog.Parse(ctx.Wd(), changelog.File(conf))
+	if err != nil {
+		return err
+	}
+
+	if len(c.Entries) > 0 && c.Entries[0].Version == version {
+		c.Entries = c.Entries[1:]
+
+		if err = c.Save(); err != nil {
+			return err
+		}
+	}
+
+	err = config.Update(ctx.Wd(), func(c *config.Configuration) {
+		c.NextVersion = ""
+	})
+	if err != nil {
 		return err
 	}
 
-	if conf.Commit {
-		if err = w.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+	if made {
+		if _, err = commitRelease(ctx, w, head.Message, true); err != nil {
+			return err
+		}
+	}
+
+	return record.Remove()
+}
+
+// releaseWorktree is the worktree without the lock of the run, which would
+// otherwise be committed and make the worktree dirty.
+func releaseWorktree(ctx *context.Context) (*git.Worktree, error) {
+	w, err := ctx.Repo().Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	w.Excludes = append(w.Excludes, gitignore.ParsePattern(ctx.RepoPath(path.Join(config.Dir, config.LockName)), nil))
+
+	return w, nil
+}
+
+// commitRelease commits every change, amending is done by committing on top of
+// the parents of HEAD as go-git amends with the tree of HEAD instead of the
+// index and refuses to amend with All.
+func commitRelease(ctx *context.Context, w *git.Worktree, msg string, amend bool) (plumbing.Hash, error) {
+	if err := w.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	opts := &git.CommitOptions{}
+
+	if amend {
+		head, err := headCommit(ctx)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		if head != nil {
+			opts.Parents = head.ParentHashes
+		}
+	}
+
+	return w.Commit(msg, opts)
+}
+
+// restore brings back the changesets consumed by the version from wherever
+// the archive mode put them, the undo record or the commit they were last in.
+// Nothing is restored when the changesets cannot be found at all.
+func (v Version) restore(ctx *context.Context, conf config.Configuration, version string, base *object.Commit, record changeset.UndoRecord, recorded bool) error {
+	wd := ctx.Wd()
+
+	switch conf.Archive {
+	case config.ArchiveDirectory:
+		cc, err := changeset.Archived(wd, version)
+		if err != nil {
 			return err
 		}
 
-		msg := "New version"
-		if len(conf.CommitMsg) > 0 {
-			msg = conf.CommitMsg
+		if len(cc) > 0 {
+			return changeset.Unarchive(wd, version)
+		}
+	case config.ArchiveManifest:
+		m, err := changelog.ReadManifest(wd)
+		if err != nil {
+			return err
+		}
+
+		if rel, ok := m.Release(version); ok {
+			// the record keeps the files byte for byte
+			if recorded {
+				err = record.Restore(wd)
+			} else {
+				for _, c := range rel.Changesets {
+					if err = c.Changeset().SaveAs(wd, c.Name); err != nil {
+						break
+					}
+				}
+			}
+
+			if err != nil {
+				return err
+			}
+
+			m.Remove(version)
+
+			return m.Save()
 		}
+	}
+
+	if recorded {
+		return record.Restore(wd)
+	}
 
-		if _, err = w.Commit(msg, &git.CommitOptions{
-			All:   true,
-			Amend: conf.AmendCommit,
-		}); err != nil {
+	committed, err := committedChangesets(ctx, base)
+	if err != nil {
+		return err
+	}
+
+	found := changeset.Changesets{}
+	for p, content := range committed {
+		c, err := changeset.Parse(content, p)
+		if err != nil {
+			return err
+		}
+
+		found = append(found, c)
+	}
+
+	if err = unrecoverable(ctx, conf, version, found); err != nil {
+		return err
+	}
+
+	for p, content := range committed {
+		if err = fsutil.WriteFile(p, []byte(content)); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// committedChangesets reads the changesets of the commit that are missing in
+// the worktree by the path they are restored to.
+func committedChangesets(ctx *context.Context, base *object.Commit) (map[string]string, error) {
+	cc := map[string]string{}
+
+	if base == nil {
+		return cc, nil
+	}
+
+	files, err := base.Files()
+	if err != nil {
+		return cc, err
+	}
+
+	err = files.ForEach(func(f *object.File) error {
+		name, ok := strings.CutPrefix(f.Name, ctx.RepoPath(config.Dir)+"/")
+		if !ok || path.Dir(name) != "." || path.Ext(name) != ".md" {
+			return nil
+		}
+
+		p := path.Join(ctx.Wd(), config.Dir, name)
+		if _, err := os.Stat(p); !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		content, err := f.Contents()
+		if err != nil {
+			return err
+		}
+
+		cc[p] = content
+
+		return nil
+	})
+
+	return cc, err
+}
+
+// unrecoverable names the changes in the changelog entry of the version that
+// none of the found changesets bring back, by their summaries.
+func unrecoverable(ctx *context.Context, conf config.Configuration, version string, found changeset.Changesets) error {
+	c, err := changelog.Parse(ctx.Wd(), changelog.File(conf))
+	if err != nil {
+		return err
+	}
+
+	e, _ := c.Entry(version)
+
+	summaries := map[string]int{}
+	for _, c := range found {
+		summaries[c.Summary]++
+	}
+
+	missing := []string{}
+
+	for _, s := range e.Sections {
+		for _, summary := range s.Content {
+			if summaries[summary] > 0 {
+				summaries[summary]--
+				continue
+			}
+
+			missing = append(missing, summary)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return errors.Wrapf(ErrUnrecoverable, "could not restore the changesets of %s (%s)", version, strings.Join(missing, "; "))
+}
+
+// releasedIn tells if the commit is the one adding the changelog entry of the
+// version to file.
+func releasedIn(ctx *context.Context, file string, commit *object.Commit, version string) (bool, error) {
+	if commit.NumParents() == 0 {
+		return false, nil
+	}
+
+	c, err := changelogAt(ctx, file, commit.Hash)
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := c.Entry(version); !ok {
+		return false, nil
+	}
+
+	if c, err = changelogAt(ctx, file, commit.ParentHashes[0]); err != nil {
+		return false, err
+	}
+
+	_, ok := c.Entry(version)
+
+	return !ok, nil
+}
+
+func headCommit(ctx *context.Context) (*object.Commit, error) {
+	h, err := ctx.Repo().Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.Repo().CommitObject(h.Hash())
+}
+
+func commitMsg(conf config.Configuration) string {
+	if len(conf.CommitMsg) > 0 {
+		return conf.CommitMsg
+	}
+
+	return defaultCommitMsg
+}
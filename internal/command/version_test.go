@@ -0,0 +1,100 @@
+package command
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"versioner/internal/config"
+	"versioner/internal/context"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestVersionAmendKeepsIndex(t *testing.T) {
+	gitHome(t)
+
+	wd := t.TempDir()
+
+	repo, err := git.PlainInit(wd, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(wd, "go.mod"), "module example\n")
+	commitAll(t, repo, "Initial commit")
+
+	conf := config.Configuration{Commit: true, AmendCommit: true}
+	if err = config.Create(wd, "json", conf); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(wd, config.Dir, "some_fix.md"), "---\nfix\n---\n\nFix something\n\n")
+	writeFile(t, filepath.Join(wd, "fix.go"), "package example\n")
+	commitAll(t, repo, "Fix something")
+
+	before, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent, err := repo.CommitObject(before.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.New(repo, wd, wd)
+	if err = (Version{}).Run(&ctx); err != nil {
+		t.Fatalf("version: %v", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(commit.ParentHashes) != 1 || commit.ParentHashes[0] != parent.ParentHashes[0] {
+		t.Errorf("release commit is not amended: parents %v, want %v", commit.ParentHashes, parent.ParentHashes)
+	}
+
+	changelog, err := commit.File("CHANGELOG.md")
+	if err != nil {
+		t.Fatalf("changelog is not in the amended commit: %v", err)
+	}
+
+	content, err := changelog.Contents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(content, "Fix something") {
+		t.Errorf("changelog of the amended commit has no entry:\n%s", content)
+	}
+
+	if _, err = commit.File("fix.go"); err != nil {
+		t.Errorf("amended commit lost the change it amends: %v", err)
+	}
+
+	if _, err = commit.File(filepath.Join(config.Dir, "some_fix.md")); err != object.ErrFileNotFound {
+		t.Errorf("consumed changeset is still in the amended commit: %v", err)
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !status.IsClean() {
+		t.Errorf("worktree is dirty after the release:\n%s", status)
+	}
+}
@@ -1,15 +1,27 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
-	"os"
+	"fmt"
 	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"versioner/internal/fsutil"
 )
 
 const (
 	Dir      = ".versioner"
 	FileName = "config.json"
+
+	ArchiveDirectory = "directory"
+	ArchiveManifest  = "manifest"
+
+	// VersionPlaceholder is replaced by the version in TagTemplate.
+	VersionPlaceholder = "{version}"
 )
 
 var (
@@ -18,60 +30,542 @@ var (
 )
 
 type Configuration struct {
+	// Schema points editors at the JSON Schema of the config, see SchemaURL.
+	Schema string `json:"$schema,omitempty"`
+	// SchemaVersion is the version of the config format the config is
+	// written in, see LatestSchemaVersion.
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+
 	BaseBranch  string   `json:"baseBranch,omitempty"`
 	Ignore      []string `json:"ignore,omitempty"`
 	Commit      bool     `json:"commit,omitempty"`
 	CommitMsg   string   `json:"commitMsg,omitempty"`
 	AmendCommit bool     `json:"amendCommit,omitempty"`
 	NextVersion string   `json:"nextVersion,omitempty"`
+	SignKey     string   `json:"signKey,omitempty"`
+	Push        bool     `json:"push,omitempty"`
+	Remote      string   `json:"remote,omitempty"`
+	SSHKey      string   `json:"sshKey,omitempty"`
+	Archive     string   `json:"archive,omitempty"`
+	// ArchiveRetention is the number of releases to keep archived changesets
+	// for, zero keeps all of them.
+	ArchiveRetention int `json:"archiveRetention,omitempty"`
+	// SummaryLimit is the number of characters a summary should stay within,
+	// going over it is only pointed out when writing the summary.
+	SummaryLimit int `json:"summaryLimit,omitempty"`
+	// TagTemplate names the version tags, like "v{version}", empty tags the
+	// bare version.
+	TagTemplate string `json:"tagTemplate,omitempty"`
+	// Changelog is the path of the changelog relative to the project,
+	// CHANGELOG.md when empty.
+	Changelog string `json:"changelog,omitempty"`
+	// Types adds custom conventional types, a type using the key of a built
+	// in type replaces it.
+	Types []Type `json:"types,omitempty"`
+	Theme *Theme `json:"theme,omitempty"`
+	// Keys replaces the keys of the prompts by the name of the binding, like
+	// "submit" or "back".
+	Keys map[string][]string `json:"keys,omitempty"`
+}
+
+// Theme changes the colors and symbols of the prompts, colors are ANSI
+// numbers or hex codes and empty values keep the defaults.
+type Theme struct {
+	Accent           string `json:"accent,omitempty"`
+	Subdued          string `json:"subdued,omitempty"`
+	Inactive         string `json:"inactive,omitempty"`
+	Error            string `json:"error,omitempty"`
+	Text             string `json:"text,omitempty"`
+	Prompt           string `json:"prompt,omitempty"`
+	Cursor           string `json:"cursor,omitempty"`
+	SelectedPrefix   string `json:"selectedPrefix,omitempty"`
+	UnselectedPrefix string `json:"unselectedPrefix,omitempty"`
+}
+
+type Type struct {
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	Level         string `json:"level,omitempty"`
+	CanBeBreaking bool   `json:"canBeBreaking,omitempty"`
+}
+
+// TagName is the name of the tag of the version.
+func (c Configuration) TagName(version string) string {
+	if len(c.TagTemplate) == 0 {
+		return version
+	}
+
+	return strings.ReplaceAll(c.TagTemplate, VersionPlaceholder, version)
 }
 
 func Ensure(wd string) error {
-	if _, err := os.Stat(path.Join(wd, Dir, FileName)); os.IsNotExist(err) {
+	if _, ok := RepoFile(wd); !ok {
 		return NotInitialized
 	}
 
 	return nil
 }
 
+// Read merges the layers of the config, see ReadWithOrigins.
 func Read(wd string) (Configuration, error) {
-	var config Configuration
+	config, _, err := ReadWithOrigins(wd)
+	return config, err
+}
+
+// Set writes the config as the repo layer, in the format the repo config is
+// already written in. Keys keeping their value are left as they are written.
+func Set(wd string, conf Configuration) error {
+	values, err := tree(conf)
+	if err != nil {
+		return err
+	}
+
+	return updateTree(wd, func(current map[string]any) error {
+		for k := range current {
+			delete(current, k)
+		}
+
+		for k, v := range values {
+			current[k] = v
+		}
+
+		return nil
+	})
+}
 
-	if err := Ensure(wd); err != nil {
-		return config, err
+// Create writes the first repo config, format is the extension of one of
+// Extensions without the dot.
+func Create(wd, format string, conf Configuration) error {
+	conf.SchemaVersion = LatestSchemaVersion
+	if format == "json" {
+		conf.Schema = SchemaURL
 	}
 
-	b, err := os.ReadFile(path.Join(wd, Dir, FileName))
+	configPath := path.Join(wd, Dir, strings.TrimSuffix(FileName, path.Ext(FileName))+"."+format)
+
+	b, err := encode(configPath, conf)
 	if err != nil {
-		return config, err
+		return err
 	}
 
-	if err = json.Unmarshal(b, &config); err != nil {
-		return config, err
+	if err = fsutil.MkdirAll(path.Join(wd, Dir)); err != nil {
+		return err
 	}
 
-	return config, nil
+	return fsutil.WriteFile(configPath, b)
 }
 
-func Set(wd string, conf Configuration) error {
-	if err := Ensure(wd); err != nil {
+// Update changes the repo layer alone, so values from the other layers do
+// not end up in the repo config. Only the keys changed by fn are written.
+func Update(wd string, fn func(*Configuration)) error {
+	conf, _, err := readRepo(wd)
+	if err != nil {
+		return err
+	}
+
+	before, err := tree(conf)
+	if err != nil {
+		return err
+	}
+
+	fn(&conf)
+
+	after, err := tree(conf)
+	if err != nil {
+		return err
+	}
+
+	return updateTree(wd, func(values map[string]any) error {
+		for k := range before {
+			if _, ok := after[k]; !ok {
+				delete(values, k)
+			}
+		}
+
+		for k, v := range after {
+			if !equal(before[k], v) {
+				values[k] = v
+			}
+		}
+
+		return nil
+	})
+}
+
+// Get finds the value of a dotted key in the config, keys left unset have
+// the zero value of their type.
+func Get(conf Configuration, key string) (any, error) {
+	t, ok := fieldType(key)
+	if !ok {
+		return nil, unknownKey(key)
+	}
+
+	values, err := tree(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var v any = values
+	for _, p := range strings.Split(key, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			v = nil
+			break
+		}
+
+		v = m[p]
+	}
+
+	if v != nil {
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		return []any{}, nil
+	case reflect.Map, reflect.Pointer:
+		return map[string]any{}, nil
+	}
+
+	return reflect.Zero(t).Interface(), nil
+}
+
+// SetValue sets a dotted key of the repo layer, the text is parsed by the type
+// of the key like values from the environment.
+func SetValue(wd, key, text string) error {
+	return updateTree(wd, func(values map[string]any) error {
+		return setValue(values, key, text)
+	})
+}
+
+// Unset removes a dotted key from the repo layer, so the value of a lower
+// layer applies again.
+func Unset(wd, key string) error {
+	if _, ok := fieldType(key); !ok {
+		return unknownKey(key)
+	}
+
+	return updateTree(wd, func(values map[string]any) error {
+		unset(values, strings.Split(key, "."))
+		return nil
+	})
+}
+
+// updateTree changes the values of the repo config as they are written, not
+// migrated, and writes them back if they pass the checks of readLayer.
+func updateTree(wd string, fn func(map[string]any) error) error {
+	file, ok := RepoFile(wd)
+	if !ok {
+		return NotInitialized
+	}
+
+	// unknown keys would be lost when the config is written back
+	if _, err := readLayer(file); err != nil {
+		return err
+	}
+
+	doc, err := readDocument(file)
+	if err != nil {
 		return err
 	}
 
-	configPath := path.Join(wd, Dir, FileName)
+	values, err := doc.values()
+	if err != nil {
+		return err
+	}
 
-	if err := os.Remove(configPath); err != nil {
+	if err = fn(values); err != nil {
 		return err
 	}
 
-	b, err := json.MarshalIndent(&conf, "", "  ")
+	b, err := json.Marshal(values)
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(configPath, b, os.ModePerm); err != nil {
+	result := map[string]any{}
+	if err = json.Unmarshal(b, &result); err != nil {
 		return err
 	}
 
-	return nil
+	if err = validate(file, result); err != nil {
+		return err
+	}
+
+	if err = doc.sync(values); err != nil {
+		return err
+	}
+
+	return doc.save()
+}
+
+// unset deletes the key and the objects left empty by it.
+func unset(values map[string]any, parts []string) {
+	if len(parts) == 1 {
+		delete(values, parts[0])
+		return
+	}
+
+	next, ok := values[parts[0]].(map[string]any)
+	if !ok {
+		return
+	}
+
+	unset(next, parts[1:])
+
+	if len(next) == 0 {
+		delete(values, parts[0])
+	}
+}
+
+func contains(values []any, value any) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func enumText(values []any) string {
+	texts := []string{}
+	for _, v := range values {
+		if s, ok := v.(string); ok && len(s) > 0 {
+			texts = append(texts, s)
+		}
+	}
+
+	return strings.Join(texts, ", ") + " or empty"
+}
+
+type Problem struct {
+	Line    int
+	Message string
+}
+
+// Validate checks the raw config against the fields of Configuration, unlike
+// Read it reports every unknown key and mistyped value.
+func Validate(b []byte) []Problem {
+	return ValidateFile(FileName, b)
+}
+
+// ValidateFile checks a config file of any of the Extensions like Validate.
+func ValidateFile(file string, b []byte) []Problem {
+	values, err := decodeTree(file, b)
+	if err == nil {
+		_, _, err = migrate(values)
+	}
+
+	if err != nil {
+		line := 1
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line = lineAt(b, int(syntaxErr.Offset))
+		}
+
+		return []Problem{{Line: line, Message: err.Error()}}
+	}
+
+	problems := []Problem{}
+
+	for _, kp := range check(values, reflect.TypeOf(Configuration{}), "") {
+		name := kp.name
+		if path.Ext(file) == ".json" {
+			name = fmt.Sprintf("%q", name)
+		}
+
+		problems = append(problems, Problem{Line: lineAt(b, bytes.Index(b, []byte(name))), Message: kp.message})
+	}
+
+	return problems
+}
+
+// indices are the positions in lists of a key, like [0] in types[0].level.
+var indices = regexp.MustCompile(`\[\d+\]`)
+
+// keyProblem is a problem with the key name in the file, the message holds
+// the whole dotted key.
+type keyProblem struct {
+	name    string
+	message string
+}
+
+// check compares the values of a config file with the fields of t, reporting
+// unknown keys with the closest known key, values of the wrong type and
+// required values left empty.
+func check(values map[string]any, t reflect.Type, prefix string) []keyProblem {
+	kk := make([]string, 0, len(values))
+	for k := range values {
+		kk = append(kk, k)
+	}
+	sort.Strings(kk)
+
+	problems := []keyProblem{}
+
+	for _, k := range kk {
+		key := join(prefix, k)
+
+		f, ok := fieldByName(t, k)
+		if !ok {
+			message := fmt.Sprintf("unknown key %q", key)
+			if s := suggest(k, fieldNames(t)); len(s) > 0 {
+				message += fmt.Sprintf(", did you mean %q?", join(prefix, s))
+			}
+
+			problems = append(problems, keyProblem{name: k, message: message})
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		switch {
+		case ft.Kind() == reflect.Struct:
+			if m, ok := values[k].(map[string]any); ok {
+				problems = append(problems, check(m, ft, key)...)
+				continue
+			}
+		case ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct:
+			if list, ok := values[k].([]any); ok {
+				valid := true
+				for i, e := range list {
+					m, ok := e.(map[string]any)
+					if !ok {
+						valid = false
+						break
+					}
+
+					problems = append(problems, check(m, ft.Elem(), fmt.Sprintf("%s[%d]", key, i))...)
+				}
+
+				if valid {
+					continue
+				}
+			}
+		default:
+			b, err := json.Marshal(values[k])
+			if err == nil && json.Unmarshal(b, reflect.New(ft).Interface()) == nil {
+				if allowed, ok := enums[indices.ReplaceAllString(key, "")]; ok && !contains(allowed, values[k]) {
+					problems = append(problems, keyProblem{name: k, message: fmt.Sprintf("%q must be one of %s", key, enumText(allowed))})
+				}
+
+				continue
+			}
+		}
+
+		problems = append(problems, keyProblem{name: k, message: fmt.Sprintf("%q must be %s", key, typeName(ft))})
+	}
+
+	for _, name := range requiredNames(t) {
+		if v, ok := values[name]; !ok || v == "" {
+			problems = append(problems, keyProblem{name: name, message: fmt.Sprintf("%q is required", join(prefix, name))})
+		}
+	}
+
+	return problems
+}
+
+// requiredNames are the JSON names of the fields of t that are not left out
+// when empty, like the title of a type, as required by Schema.
+func requiredNames(t reflect.Type) []string {
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		if !strings.Contains(t.Field(i).Tag.Get("json"), ",omitempty") {
+			names = append(names, jsonName(t.Field(i)))
+		}
+	}
+
+	return names
+}
+
+// typeName describes a type the way it is written in a config file.
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int:
+		return "a number"
+	case reflect.Slice:
+		switch t.Elem().Kind() {
+		case reflect.String:
+			return "a list of strings"
+		case reflect.Struct:
+			return "a list of objects"
+		}
+		return "a list"
+	case reflect.Pointer:
+		return typeName(t.Elem())
+	}
+
+	return "an object"
+}
+
+func fieldNames(t reflect.Type) []string {
+	names := make([]string, t.NumField())
+	for i := range names {
+		names[i] = jsonName(t.Field(i))
+	}
+
+	return names
+}
+
+// suggest finds the known name closest to a mistyped one, nothing is
+// suggested when every name is too far off.
+func suggest(name string, names []string) string {
+	best, bestDistance := "", len(name)/2+1
+
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return n
+		}
+
+		if d := distance(strings.ToLower(name), strings.ToLower(n)); d < bestDistance {
+			best, bestDistance = n, d
+		}
+	}
+
+	return best
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev = curr
+	}
+
+	return prev[len(b)]
+}
+
+func lineAt(b []byte, offset int) int {
+	if offset < 0 {
+		return 1
+	}
+
+	if offset > len(b) {
+		offset = len(b)
+	}
+
+	return bytes.Count(b[:offset], []byte("\n")) + 1
 }
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Problem
+	}{
+		{
+			name:    "top level",
+			content: "{\n  \"commitMsgs\": \"x\"\n}",
+			want:    []Problem{{Line: 2, Message: `unknown key "commitMsgs", did you mean "commitMsg"?`}},
+		},
+		{
+			name:    "nested",
+			content: "{\n  \"theme\": {\n    \"acent\": \"5\"\n  }\n}",
+			want:    []Problem{{Line: 3, Message: `unknown key "theme.acent", did you mean "theme.accent"?`}},
+		},
+		{
+			name:    "in a list",
+			content: "{\n  \"types\": [\n    {\"type\": \"x\", \"title\": \"X\", \"levl\": \"minor\"}\n  ]\n}",
+			want:    []Problem{{Line: 3, Message: `unknown key "types[0].levl", did you mean "types[0].level"?`}},
+		},
+		{
+			name:    "nothing close",
+			content: "{\n  \"frobnicate\": true\n}",
+			want:    []Problem{{Line: 2, Message: `unknown key "frobnicate"`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Validate([]byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadUnknownKey(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	wd, _ := writeRepoConfig(t, "config.yaml", "theme:\n    acent: \"5\"\n")
+
+	_, err := Read(wd)
+	if !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), `unknown key "theme.acent", did you mean "theme.accent"?`) {
+		t.Errorf("got %v, want the unknown key with its path", err)
+	}
+}
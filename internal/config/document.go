@@ -0,0 +1,426 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"versioner/internal/fsutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// document is a config file as a tree of YAML nodes whatever its format, so
+// changing a value keeps the order of the other keys, the comments and the
+// indent of the file. Values are written in the layout of encoding/json, YAML
+// or TOML, like lists on one line in TOML.
+type document struct {
+	file string
+	node *yaml.Node
+	// indent of the file, spaces or a tab for JSON, a number of spaces for
+	// YAML and the indent of the keys in tables for TOML.
+	indent string
+	// newline tells if the file ends in a line break.
+	newline bool
+}
+
+func readDocument(file string) (*document, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &document{
+		file:    file,
+		indent:  detectIndent(b),
+		newline: len(b) == 0 || bytes.HasSuffix(b, []byte("\n")),
+	}
+
+	switch path.Ext(file) {
+	case ".yaml", ".yml":
+		d.node = &yaml.Node{}
+		err = yaml.Unmarshal(b, d.node)
+	case ".toml":
+		d.node, d.indent, err = tomlNode(b)
+	default:
+		d.node, err = jsonNode(json.NewDecoder(bytes.NewReader(b)))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", file, err)
+	}
+
+	if d.node.Kind == 0 {
+		d.node = &yaml.Node{Kind: yaml.DocumentNode}
+	}
+
+	if d.node.Kind == yaml.DocumentNode && len(d.node.Content) == 0 {
+		d.node.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	if d.root().Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("could not read %s: config must be an object", file)
+	}
+
+	return d, nil
+}
+
+// root is the mapping of the top level keys.
+func (d *document) root() *yaml.Node {
+	if d.node.Kind == yaml.DocumentNode {
+		return d.node.Content[0]
+	}
+
+	return d.node
+}
+
+// sync changes the document to hold the values, keys with an equal value are
+// left as they are.
+func (d *document) sync(values map[string]any) error {
+	return syncMapping(d.root(), values)
+}
+
+func syncMapping(m *yaml.Node, values map[string]any) error {
+	kept := m.Content[:0]
+
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if _, ok := values[m.Content[i].Value]; ok {
+			kept = append(kept, m.Content[i], m.Content[i+1])
+		}
+	}
+
+	m.Content = kept
+
+	kk := make([]string, 0, len(values))
+	for k := range values {
+		kk = append(kk, k)
+	}
+	sort.Strings(kk)
+
+	for _, k := range kk {
+		current := lookup(m, k)
+
+		if v, ok := values[k].(map[string]any); ok && current != nil && current.Kind == yaml.MappingNode {
+			if err := syncMapping(current, v); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if current != nil {
+			var decoded any
+			if err := current.Decode(&decoded); err != nil {
+				return err
+			}
+
+			if equal(decoded, values[k]) {
+				continue
+			}
+		}
+
+		n, err := valueNode(values[k])
+		if err != nil {
+			return err
+		}
+
+		put(m, k, n)
+	}
+
+	return nil
+}
+
+// values decodes the document as encoding/json would.
+func (d *document) values() (map[string]any, error) {
+	var v any
+	if err := d.root().Decode(&v); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]any{}
+
+	return values, json.Unmarshal(b, &values)
+}
+
+func (d *document) encode() ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch path.Ext(d.file) {
+	case ".yaml", ".yml":
+		indent := len(d.indent)
+		if indent < 2 {
+			indent = 4
+		}
+
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(indent)
+
+		if err := enc.Encode(d.node); err != nil {
+			return nil, err
+		}
+
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	case ".toml":
+		if err := writeTOML(&buf, d.root(), "", d.indent); err != nil {
+			return nil, err
+		}
+
+		if foot := d.root().FootComment; len(foot) > 0 {
+			buf.WriteString("\n" + foot + "\n")
+		}
+
+		return bytes.TrimLeft(buf.Bytes(), "\n"), nil
+	}
+
+	indent := d.indent
+	if len(indent) == 0 {
+		indent = "  "
+	}
+
+	if err := writeJSON(&buf, d.root(), indent, ""); err != nil {
+		return nil, err
+	}
+
+	if d.newline {
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (d *document) save() error {
+	b, err := d.encode()
+	if err != nil {
+		return err
+	}
+
+	return fsutil.WriteFile(d.file, b)
+}
+
+// detectIndent takes the indent of the first indented line.
+func detectIndent(b []byte) string {
+	for _, line := range strings.Split(string(b), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if len(trimmed) == 0 || len(trimmed) == len(line) || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		return line[:len(line)-len(trimmed)]
+	}
+
+	return ""
+}
+
+func lookup(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// put replaces the value of the key in place, keeping the comment at the end
+// of the line, or adds the key at the end.
+func put(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			value.LineComment = m.Content[i+1].LineComment
+			m.Content[i+1] = value
+
+			return
+		}
+	}
+
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func valueNode(v any) (*yaml.Node, error) {
+	n := &yaml.Node{}
+
+	return n, n.Encode(integers(v))
+}
+
+func equal(a, b any) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(x, y)
+}
+
+// jsonNode reads the next JSON value into a node, keeping the order of keys.
+func jsonNode(dec *json.Decoder) (*yaml.Node, error) {
+	dec.UseNumber()
+
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := t.(type) {
+	case json.Delim:
+		if t == '{' {
+			m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				v, err := jsonNode(dec)
+				if err != nil {
+					return nil, err
+				}
+
+				m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k.(string)}, v)
+			}
+
+			_, err = dec.Token()
+
+			return m, err
+		}
+
+		s := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+		for dec.More() {
+			v, err := jsonNode(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			s.Content = append(s.Content, v)
+		}
+
+		_, err = dec.Token()
+
+		return s, err
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: t.String()}, nil
+		}
+
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: t.String()}, nil
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+
+	return valueNode(t)
+}
+
+func writeJSON(w io.Writer, n *yaml.Node, indent, prefix string) error {
+	inner := prefix + indent
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		if len(n.Content) == 0 {
+			_, err := io.WriteString(w, "{}")
+			return err
+		}
+
+		io.WriteString(w, "{\n")
+
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, err := jsonString(n.Content[i].Value)
+			if err != nil {
+				return err
+			}
+
+			io.WriteString(w, inner+key+": ")
+
+			if err = writeJSON(w, n.Content[i+1], indent, inner); err != nil {
+				return err
+			}
+
+			if i+2 < len(n.Content) {
+				io.WriteString(w, ",")
+			}
+
+			io.WriteString(w, "\n")
+		}
+
+		_, err := io.WriteString(w, prefix+"}")
+
+		return err
+	case yaml.SequenceNode:
+		if len(n.Content) == 0 {
+			_, err := io.WriteString(w, "[]")
+			return err
+		}
+
+		io.WriteString(w, "[\n")
+
+		for i, e := range n.Content {
+			io.WriteString(w, inner)
+
+			if err := writeJSON(w, e, indent, inner); err != nil {
+				return err
+			}
+
+			if i+1 < len(n.Content) {
+				io.WriteString(w, ",")
+			}
+
+			io.WriteString(w, "\n")
+		}
+
+		_, err := io.WriteString(w, prefix+"]")
+
+		return err
+	}
+
+	s, err := scalarText(n)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, s)
+
+	return err
+}
+
+// scalarText writes a scalar as JSON, which TOML reads the same way.
+func scalarText(n *yaml.Node) (string, error) {
+	switch n.ShortTag() {
+	case "!!int", "!!float", "!!bool":
+		return n.Value, nil
+	case "!!null":
+		return "null", nil
+	}
+
+	return jsonString(n.Value)
+}
+
+func jsonString(s string) (string, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(s); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,293 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const jsonConfig = `{
+    "$schema": "https://example.com/config.schema.json",
+    "nextVersion": "0.1.0",
+    "commit": true,
+    "types": [
+        {
+            "type": "perf",
+            "title": "Performance <fast>"
+        }
+    ]
+}
+`
+
+const yamlConfig = `# release settings
+nextVersion: 0.1.0 # bumped by version
+commit: true
+baseBranch: main
+# custom types
+types:
+    - type: perf
+      title: Performance
+`
+
+const tomlConfig = `# versioner
+nextVersion = "0.1.0"
+commit = true # release commits
+ignore = ["docs/**", "*.txt"]
+
+# prompts
+[theme]
+  accent = "5"
+
+# custom types
+[[types]]
+  title = "Performance"
+  type = "perf" # fast
+
+[[types]]
+  level = "minor"
+  title = "Security"
+  type = "sec"
+
+# the end
+`
+
+// writeRepoConfig makes a project with the repo config as content.
+func writeRepoConfig(t *testing.T, name, content string) (string, string) {
+	t.Helper()
+
+	wd := t.TempDir()
+	file := filepath.Join(wd, Dir, name)
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return wd, file
+}
+
+func readFile(t *testing.T, file string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestSetValueKeepsLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		key     string
+		value   string
+		// original is the value written back, empty unsets the key
+		original string
+		want     string
+	}{
+		{
+			name:     "json new key",
+			file:     "config.json",
+			content:  jsonConfig,
+			key:      "theme.accent",
+			value:    "5",
+			original: "",
+			want: `{
+    "$schema": "https://example.com/config.schema.json",
+    "nextVersion": "0.1.0",
+    "commit": true,
+    "types": [
+        {
+            "type": "perf",
+            "title": "Performance <fast>"
+        }
+    ],
+    "theme": {
+        "accent": "5"
+    }
+}
+`,
+		},
+		{
+			name:     "json existing key",
+			file:     "config.json",
+			content:  jsonConfig,
+			key:      "commit",
+			value:    "false",
+			original: "true",
+			want: `{
+    "$schema": "https://example.com/config.schema.json",
+    "nextVersion": "0.1.0",
+    "commit": false,
+    "types": [
+        {
+            "type": "perf",
+            "title": "Performance <fast>"
+        }
+    ]
+}
+`,
+		},
+		{
+			name:     "yaml existing key",
+			file:     "config.yaml",
+			content:  yamlConfig,
+			key:      "nextVersion",
+			value:    "0.2.0",
+			original: "0.1.0",
+			want: `# release settings
+nextVersion: 0.2.0 # bumped by version
+commit: true
+baseBranch: main
+# custom types
+types:
+    - type: perf
+      title: Performance
+`,
+		},
+		{
+			name:     "yaml new key",
+			file:     "config.yaml",
+			content:  yamlConfig,
+			key:      "archive",
+			value:    "manifest",
+			original: "",
+			want: `# release settings
+nextVersion: 0.1.0 # bumped by version
+commit: true
+baseBranch: main
+# custom types
+types:
+    - type: perf
+      title: Performance
+archive: manifest
+`,
+		},
+		{
+			name:     "toml existing key",
+			file:     "config.toml",
+			content:  tomlConfig,
+			key:      "commit",
+			value:    "false",
+			original: "true",
+			want: `# versioner
+nextVersion = "0.1.0"
+commit = false # release commits
+ignore = ["docs/**", "*.txt"]
+
+# prompts
+[theme]
+  accent = "5"
+
+# custom types
+[[types]]
+  title = "Performance"
+  type = "perf" # fast
+
+[[types]]
+  level = "minor"
+  title = "Security"
+  type = "sec"
+
+# the end
+`,
+		},
+		{
+			name:     "toml new key in table",
+			file:     "config.toml",
+			content:  tomlConfig,
+			key:      "theme.cursor",
+			value:    ">",
+			original: "",
+			want: `# versioner
+nextVersion = "0.1.0"
+commit = true # release commits
+ignore = ["docs/**", "*.txt"]
+
+# prompts
+[theme]
+  accent = "5"
+  cursor = ">"
+
+# custom types
+[[types]]
+  title = "Performance"
+  type = "perf" # fast
+
+[[types]]
+  level = "minor"
+  title = "Security"
+  type = "sec"
+
+# the end
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wd, file := writeRepoConfig(t, tt.file, tt.content)
+
+			if err := SetValue(wd, tt.key, tt.value); err != nil {
+				t.Fatalf("set %s: %v", tt.key, err)
+			}
+
+			if got := readFile(t, file); got != tt.want {
+				t.Errorf("after set:\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			var err error
+			if len(tt.original) > 0 {
+				err = SetValue(wd, tt.key, tt.original)
+			} else {
+				err = Unset(wd, tt.key)
+			}
+
+			if err != nil {
+				t.Fatalf("restore %s: %v", tt.key, err)
+			}
+
+			if got := readFile(t, file); got != tt.content {
+				t.Errorf("after restoring:\n%s\nwant:\n%s", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestSetValueRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		key     string
+		value   string
+	}{
+		{name: "json type without title", file: "config.json", content: jsonConfig, key: "types", value: `[{"type":"x"}]`},
+		{name: "json unknown archive", file: "config.json", content: jsonConfig, key: "archive", value: "dir"},
+		{name: "yaml empty title", file: "config.yaml", content: yamlConfig, key: "types", value: `[{"type":"x","title":""}]`},
+		{name: "yaml unknown level", file: "config.yaml", content: yamlConfig, key: "types", value: `[{"type":"x","title":"X","level":"huge"}]`},
+		{name: "toml unknown archive", file: "config.toml", content: tomlConfig, key: "archive", value: "dir"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wd, file := writeRepoConfig(t, tt.file, tt.content)
+
+			err := SetValue(wd, tt.key, tt.value)
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("set %s %s: got %v, want %v", tt.key, tt.value, err, ErrInvalid)
+			}
+
+			if got := readFile(t, file); got != tt.content {
+				t.Errorf("config was written:\n%s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,574 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// The layers of the config from the lowest precedence to the highest.
+const (
+	LayerDefault = "default"
+	LayerUser    = "user"
+	LayerRepo    = "repo"
+	LayerEnv     = "env"
+	LayerFlag    = "flag"
+
+	envPrefix = "VERSIONER_"
+)
+
+// Extensions are the formats a config file can be written in, in the order
+// they are looked for.
+var Extensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+var (
+	ErrUnknownKey = errors.New("unknown config key")
+	ErrInvalid    = errors.New("invalid config")
+)
+
+// overrides are the values set on the command line.
+var overrides = map[string]string{}
+
+// Origins tell for each dotted key where its value comes from, like
+// "repo (.versioner/config.yaml)".
+type Origins map[string]string
+
+// Defaults are the values used when no layer sets them.
+func Defaults() Configuration {
+	return Configuration{
+		CommitMsg: "New version",
+		Remote:    "origin",
+	}
+}
+
+// Override sets values from the command line on top of every other layer,
+// keys are dotted paths like theme.accent.
+func Override(values map[string]string) error {
+	for k := range values {
+		if _, ok := fieldType(k); !ok {
+			return unknownKey(k)
+		}
+	}
+
+	overrides = values
+
+	return nil
+}
+
+// RepoFile is the config file of the project, in whichever format it exists.
+func RepoFile(wd string) (string, bool) {
+	return findFile(path.Join(wd, Dir, strings.TrimSuffix(FileName, path.Ext(FileName))))
+}
+
+// UserFile is the config file shared by every project of the user, in
+// $XDG_CONFIG_HOME/versioner or ~/.config/versioner on every OS.
+func UserFile() (string, bool) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if len(dir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+
+		dir = path.Join(home, ".config")
+	}
+
+	return findFile(path.Join(dir, "versioner", "config"))
+}
+
+func findFile(base string) (string, bool) {
+	for _, ext := range Extensions {
+		if _, err := os.Stat(base + ext); err == nil {
+			return base + ext, true
+		}
+	}
+
+	return base + ".json", false
+}
+
+// ReadWithOrigins merges the layers of the config and tells where each value
+// comes from.
+func ReadWithOrigins(wd string) (Configuration, Origins, error) {
+	var config Configuration
+
+	if err := Ensure(wd); err != nil {
+		return config, nil, err
+	}
+
+	merged := map[string]any{}
+	origins := Origins{}
+
+	defaults, err := tree(Defaults())
+	if err != nil {
+		return config, nil, err
+	}
+	merge(merged, defaults, "", LayerDefault, origins)
+
+	if file, ok := UserFile(); ok {
+		values, err := readLayer(file)
+		if err != nil {
+			return config, nil, err
+		}
+		merge(merged, values, "", fmt.Sprintf("%s (%s)", LayerUser, file), origins)
+	}
+
+	file, _ := RepoFile(wd)
+	values, err := readLayer(file)
+	if err != nil {
+		return config, nil, err
+	}
+	merge(merged, values, "", fmt.Sprintf("%s (%s)", LayerRepo, relative(wd, file)), origins)
+
+	for _, key := range keys() {
+		env := envName(key)
+
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+
+		if err = setValue(merged, key, value); err != nil {
+			return config, nil, fmt.Errorf("%s: %w", env, err)
+		}
+		origins[key] = fmt.Sprintf("%s (%s)", LayerEnv, env)
+	}
+
+	flags := make([]string, 0, len(overrides))
+	for k := range overrides {
+		flags = append(flags, k)
+	}
+	sort.Strings(flags)
+
+	for _, key := range flags {
+		if err = setValue(merged, key, overrides[key]); err != nil {
+			return config, nil, fmt.Errorf("-c %s: %w", key, err)
+		}
+		origins[key] = fmt.Sprintf("%s (-c %s=%s)", LayerFlag, key, overrides[key])
+	}
+
+	config, err = decode(merged)
+
+	return config, origins, err
+}
+
+// readRepo reads the repo layer alone, which is the layer written back.
+func readRepo(wd string) (Configuration, string, error) {
+	file, ok := RepoFile(wd)
+	if !ok {
+		return Configuration{}, file, NotInitialized
+	}
+
+	values, err := readLayer(file)
+	if err != nil {
+		return Configuration{}, file, err
+	}
+
+	config, err := decode(values)
+
+	return config, file, err
+}
+
+// readLayer reads a config file migrated to LatestSchemaVersion, unknown keys
+// and values of the wrong type are errors rather than left out.
+func readLayer(file string) (map[string]any, error) {
+	values, err := readTree(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validate(file, values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// validate migrates the values of the config file in place and checks them,
+// unknown keys, values of the wrong type or outside of enums and missing
+// required values are errors.
+func validate(file string, values map[string]any) error {
+	if _, _, err := migrate(values); err != nil {
+		return fmt.Errorf("%w in %s: %w", ErrInvalid, file, err)
+	}
+
+	problems := check(values, reflect.TypeOf(Configuration{}), "")
+	if len(problems) > 0 {
+		messages := make([]string, len(problems))
+		for i, p := range problems {
+			messages[i] = p.message
+		}
+
+		return fmt.Errorf("%w in %s: %s", ErrInvalid, file, strings.Join(messages, ", "))
+	}
+
+	return nil
+}
+
+// decode turns plain maps and slices back into the config.
+func decode(values map[string]any) (Configuration, error) {
+	var config Configuration
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return config, err
+	}
+
+	return config, json.Unmarshal(b, &config)
+}
+
+// readTree decodes a config file of any of the Extensions into plain maps and
+// slices as encoding/json would.
+func readTree(file string) (map[string]any, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeTree(file, b)
+}
+
+func decodeTree(file string, b []byte) (map[string]any, error) {
+	var err error
+
+	values := map[string]any{}
+
+	switch path.Ext(file) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &values)
+	case ".toml":
+		_, err = toml.Decode(string(b), &values)
+	default:
+		err = json.Unmarshal(b, &values)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", file, err)
+	}
+
+	if b, err = json.Marshal(values); err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", file, err)
+	}
+
+	values = map[string]any{}
+
+	return values, json.Unmarshal(b, &values)
+}
+
+// encode writes the config in the format of the file.
+func encode(file string, conf Configuration) ([]byte, error) {
+	if path.Ext(file) == ".json" {
+		return json.MarshalIndent(&conf, "", "  ")
+	}
+
+	values, err := tree(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if path.Ext(file) == ".toml" {
+		var buf bytes.Buffer
+		err = toml.NewEncoder(&buf).Encode(integers(values))
+
+		return buf.Bytes(), err
+	}
+
+	return yaml.Marshal(integers(values))
+}
+
+// tree turns the config into plain maps and slices, leaving out empty values.
+func tree(conf Configuration) (map[string]any, error) {
+	values := map[string]any{}
+
+	b, err := json.Marshal(&conf)
+	if err != nil {
+		return values, err
+	}
+
+	return values, json.Unmarshal(b, &values)
+}
+
+// integers turns whole numbers back into integers, which TOML and YAML would
+// otherwise write as floats.
+func integers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = integers(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = integers(e)
+		}
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	}
+
+	return v
+}
+
+// merge copies src over dst, objects are merged key by key and anything else
+// is replaced.
+func merge(dst, src map[string]any, prefix, origin string, origins Origins) {
+	for k, v := range src {
+		key := join(prefix, k)
+
+		if sm, ok := v.(map[string]any); ok {
+			dm, ok := dst[k].(map[string]any)
+			if !ok {
+				dm = map[string]any{}
+				dst[k] = dm
+			}
+
+			merge(dm, sm, key, origin, origins)
+			continue
+		}
+
+		dst[k] = v
+		origins[key] = origin
+	}
+}
+
+// setValue parses the text value by the type of the key and sets it in values.
+func setValue(values map[string]any, key, text string) error {
+	t, ok := fieldType(key)
+	if !ok {
+		return unknownKey(key)
+	}
+
+	v, err := parseValue(t, text)
+	if err != nil {
+		return fmt.Errorf("%q must be of type %s: %w", key, t, err)
+	}
+
+	parts := strings.Split(key, ".")
+
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := values[p].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			values[p] = next
+		}
+
+		values = next
+	}
+
+	values[parts[len(parts)-1]] = v
+
+	return nil
+}
+
+// parseValue reads a value from the environment or command line, lists of
+// strings are comma separated and anything else but scalars is JSON.
+func parseValue(t reflect.Type, text string) (any, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return text, nil
+	case reflect.Bool:
+		return strconv.ParseBool(text)
+	case reflect.Int:
+		return strconv.Atoi(text)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			values := []any{}
+			for _, s := range strings.Split(text, ",") {
+				if s = strings.TrimSpace(s); len(s) > 0 {
+					values = append(values, s)
+				}
+			}
+
+			return values, nil
+		}
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// fieldType finds the type of a dotted key, following struct fields by their
+// JSON name and maps by any key.
+func fieldType(key string) (reflect.Type, bool) {
+	t := reflect.TypeOf(Configuration{})
+
+	for _, p := range strings.Split(key, ".") {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			f, ok := fieldByName(t, p)
+			if !ok {
+				return nil, false
+			}
+			t = f.Type
+		default:
+			return nil, false
+		}
+	}
+
+	return t, true
+}
+
+// unknownKey points at the known key closest to the dotted key.
+func unknownKey(key string) error {
+	if s := suggest(key, keys()); len(s) > 0 {
+		return fmt.Errorf("%w %q, did you mean %q?", ErrUnknownKey, key, s)
+	}
+
+	return fmt.Errorf("%w %q", ErrUnknownKey, key)
+}
+
+func fieldByName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return t.Field(i), true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+func jsonName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("json"), ",")[0]
+}
+
+// keys are the dotted keys that can be set from the environment, the fields
+// of nested structs are keys of their own.
+func keys() []string {
+	return structKeys(reflect.TypeOf(Configuration{}), "")
+}
+
+func structKeys(t reflect.Type, prefix string) []string {
+	kk := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := join(prefix, jsonName(f))
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if ft.Kind() == reflect.Struct {
+			kk = append(kk, structKeys(ft, key)...)
+		} else {
+			kk = append(kk, key)
+		}
+	}
+
+	return kk
+}
+
+// envName is the environment variable of a key, theme.selectedPrefix is set
+// by VERSIONER_THEME_SELECTED_PREFIX.
+func envName(key string) string {
+	var sb strings.Builder
+
+	sb.WriteString(envPrefix)
+
+	for i, r := range key {
+		switch {
+		case r == '.':
+			sb.WriteRune('_')
+		case unicode.IsUpper(r) && i > 0:
+			sb.WriteRune('_')
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(unicode.ToUpper(r))
+		}
+	}
+
+	return sb.String()
+}
+
+// Flatten lists the value of every key that has an origin by its dotted key,
+// including the false, zero and empty values the encoded config leaves out.
+func Flatten(conf Configuration, origins Origins) (map[string]any, error) {
+	flat := map[string]any{}
+
+	for key := range origins {
+		v, err := Get(conf, key)
+		if err != nil {
+			return nil, err
+		}
+
+		flat[key] = v
+	}
+
+	return flat, nil
+}
+
+// Unflatten nests the values of Flatten back into objects.
+func Unflatten(flat map[string]any) map[string]any {
+	values := map[string]any{}
+
+	for key, v := range flat {
+		parts := strings.Split(key, ".")
+
+		m := values
+		for _, p := range parts[:len(parts)-1] {
+			next, ok := m[p].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				m[p] = next
+			}
+
+			m = next
+		}
+
+		m[parts[len(parts)-1]] = v
+	}
+
+	return values
+}
+
+func join(prefix, key string) string {
+	if len(prefix) == 0 {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+func relative(wd, file string) string {
+	if rel, err := filepath.Rel(wd, file); err == nil {
+		return rel
+	}
+
+	return file
+}
+
+// FindProject looks for the directory holding Dir from start up to root,
+// false when no directory on the way has one.
+func FindProject(start, root string) (string, bool) {
+	for dir := start; ; dir = filepath.Dir(dir) {
+		if fi, err := os.Stat(filepath.Join(dir, Dir)); err == nil && fi.IsDir() {
+			return dir, true
+		}
+
+		if dir == root || dir == filepath.Dir(dir) {
+			return "", false
+		}
+	}
+}
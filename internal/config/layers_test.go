@@ -0,0 +1,128 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeUserConfig points the user layer at a config with content.
+func writeUserConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	file := filepath.Join(dir, "versioner", name)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func override(t *testing.T, values map[string]string) {
+	t.Helper()
+
+	if err := Override(values); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { Override(map[string]string{}) })
+}
+
+func TestUserFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	if got, _ := UserFile(); got != filepath.Join(home, ".config", "versioner", "config.json") {
+		t.Errorf("without XDG_CONFIG_HOME: got %s", got)
+	}
+
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	if got, _ := UserFile(); got != filepath.Join(xdg, "versioner", "config.json") {
+		t.Errorf("with XDG_CONFIG_HOME: got %s", got)
+	}
+}
+
+func TestReadWithOrigins(t *testing.T) {
+	user := writeUserConfig(t, "config.yaml", `remote: upstream
+commit: true
+push: true
+summaryLimit: 72
+theme:
+    accent: "5"
+`)
+
+	wd, _ := writeRepoConfig(t, "config.json", `{
+    "commit": false,
+    "signKey": "",
+    "tagTemplate": "v{version}",
+    "theme": {
+        "cursor": ">"
+    }
+}
+`)
+
+	t.Setenv("VERSIONER_PUSH", "false")
+	t.Setenv("VERSIONER_THEME_ACCENT", "6")
+	override(t, map[string]string{"tagTemplate": "", "summaryLimit": "50"})
+
+	conf, origins, err := ReadWithOrigins(wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userOrigin := "user (" + user + ")"
+	repoOrigin := "repo (" + filepath.Join(Dir, "config.json") + ")"
+
+	tests := []struct {
+		key    string
+		value  any
+		origin string
+	}{
+		{key: "commitMsg", value: "New version", origin: "default"},
+		{key: "remote", value: "upstream", origin: userOrigin},
+		{key: "commit", value: false, origin: repoOrigin},
+		{key: "signKey", value: "", origin: repoOrigin},
+		{key: "theme.cursor", value: ">", origin: repoOrigin},
+		{key: "push", value: false, origin: "env (VERSIONER_PUSH)"},
+		{key: "theme.accent", value: "6", origin: "env (VERSIONER_THEME_ACCENT)"},
+		{key: "tagTemplate", value: "", origin: "flag (-c tagTemplate=)"},
+		{key: "summaryLimit", value: float64(50), origin: "flag (-c summaryLimit=50)"},
+	}
+
+	flat, err := Flatten(conf, origins)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := origins[tt.key]; got != tt.origin {
+				t.Errorf("origin: got %q, want %q", got, tt.origin)
+			}
+
+			got, ok := flat[tt.key]
+			if !ok {
+				t.Fatalf("%s is not listed", tt.key)
+			}
+
+			if !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("value: got %#v, want %#v", got, tt.value)
+			}
+		})
+	}
+
+	if conf.TagName("1.0.0") != "1.0.0" {
+		t.Errorf("tag template of the flag is not applied: %s", conf.TagName("1.0.0"))
+	}
+}
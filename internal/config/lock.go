@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"versioner/internal/fsutil"
+)
+
+// LockName is the file in Dir held while a run changes the project, it is
+// never committed.
+const LockName = "versioner.lock"
+
+var ErrLocked = errors.New("project is locked by another versioner run")
+
+// lockTimeout is how long a run waits for another one to finish, parallel
+// jobs mostly wait for a release that takes seconds.
+var lockTimeout = time.Minute
+
+const lockRetry = 100 * time.Millisecond
+
+// Lock takes the lock of the project, waiting for a run holding it to release
+// it. The returned func releases the lock.
+func Lock(wd string) (func() error, error) {
+	if err := Ensure(wd); err != nil {
+		return nil, err
+	}
+
+	file := path.Join(wd, Dir, LockName)
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, fsutil.FileMode)
+		if err == nil {
+			_, err = f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+
+			if err != nil {
+				os.Remove(file)
+				return nil, err
+			}
+
+			return func() error {
+				return os.Remove(file)
+			}, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s is held by process %s, remove it when no versioner is running", ErrLocked, file, holder(file))
+		}
+
+		time.Sleep(lockRetry)
+	}
+}
+
+func holder(file string) string {
+	b, err := os.ReadFile(file)
+	if err != nil || len(strings.TrimSpace(string(b))) == 0 {
+		return "unknown"
+	}
+
+	return strings.TrimSpace(string(b))
+}
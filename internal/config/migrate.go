@@ -0,0 +1,106 @@
+package config
+
+import "fmt"
+
+// LatestSchemaVersion is the version of the config format written by this
+// versioner, configs without schemaVersion are version 0.
+const LatestSchemaVersion = 1
+
+// SchemaURL is where the JSON Schema of the config is published.
+const SchemaURL = "https://raw.githubusercontent.com/JacobSoderblom/versioner/main/config.schema.json"
+
+type migration struct {
+	description string
+	// config changes the values of a config file in place.
+	config func(values map[string]any)
+	// changesets rewrites the changesets of the project when their format
+	// changes, it only runs when the repo config is migrated.
+	changesets func(wd string) error
+}
+
+// migrations upgrade a config from the version of their index to the next.
+var migrations = []migration{
+	{
+		description: "record the schema version",
+		config:      func(map[string]any) {},
+	},
+}
+
+// migrate upgrades the values of a config file in memory, it returns the
+// version the values were written in and the migrations applied.
+func migrate(values map[string]any) (int, []migration, error) {
+	from := 0
+
+	if v, ok := values["schemaVersion"]; ok {
+		f, ok := v.(float64)
+		if !ok || f != float64(int(f)) || f < 0 {
+			return 0, nil, fmt.Errorf("%q must be a number", "schemaVersion")
+		}
+
+		from = int(f)
+	}
+
+	if from > LatestSchemaVersion {
+		return from, nil, fmt.Errorf("config is written in schema version %d, this versioner only knows up to %d, please upgrade versioner", from, LatestSchemaVersion)
+	}
+
+	applied := migrations[from:]
+	for _, m := range applied {
+		m.config(values)
+	}
+
+	if len(applied) > 0 {
+		values["schemaVersion"] = float64(LatestSchemaVersion)
+	}
+
+	return from, applied, nil
+}
+
+// Migrate upgrades the repo config and the changesets to LatestSchemaVersion,
+// it returns the version the config was at and what was done to it. Nothing
+// is written with dryRun.
+func Migrate(wd string, dryRun bool) (int, []string, error) {
+	file, ok := RepoFile(wd)
+	if !ok {
+		return 0, nil, NotInitialized
+	}
+
+	// unknown keys would be lost when the config is written back
+	if _, err := readLayer(file); err != nil {
+		return 0, nil, err
+	}
+
+	values, err := readTree(file)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	from, applied, err := migrate(values)
+	if err != nil || len(applied) == 0 {
+		return from, nil, err
+	}
+
+	steps := make([]string, len(applied))
+	for i, m := range applied {
+		steps[i] = fmt.Sprintf("%d → %d: %s", from+i, from+i+1, m.description)
+	}
+
+	if dryRun {
+		return from, steps, nil
+	}
+
+	for _, m := range applied {
+		if m.changesets == nil {
+			continue
+		}
+
+		if err = m.changesets(wd); err != nil {
+			return from, steps, err
+		}
+	}
+
+	return from, steps, updateTree(wd, func(values map[string]any) error {
+		_, _, err := migrate(values)
+		return err
+	})
+}
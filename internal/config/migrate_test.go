@@ -0,0 +1,52 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	content := `# release settings
+nextVersion: 0.1.0 # bumped by version
+commit: true
+`
+
+	wd, file := writeRepoConfig(t, "config.yaml", content)
+
+	from, steps, err := Migrate(wd, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+
+	want := []string{"0 → 1: record the schema version"}
+	if from != 0 || !reflect.DeepEqual(steps, want) {
+		t.Errorf("dry run: got %d %q, want 0 %q", from, steps, want)
+	}
+
+	if got := readFile(t, file); got != content {
+		t.Errorf("dry run wrote the config:\n%s", got)
+	}
+
+	if _, _, err = Migrate(wd, false); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	if got := readFile(t, file); got != content+"schemaVersion: 1\n" {
+		t.Errorf("migrated config:\n%s", got)
+	}
+
+	from, steps, err = Migrate(wd, false)
+	if err != nil || from != LatestSchemaVersion || len(steps) > 0 {
+		t.Errorf("migrate again: got %d %q %v, want %d and nothing done", from, steps, err, LatestSchemaVersion)
+	}
+}
+
+func TestMigrateNewerVersion(t *testing.T) {
+	wd, _ := writeRepoConfig(t, "config.json", `{"schemaVersion": 2}`)
+
+	_, _, err := Migrate(wd, false)
+	if err == nil || !strings.Contains(err.Error(), "please upgrade versioner") {
+		t.Errorf("got %v, want an error asking to upgrade", err)
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// descriptions are shown by editors next to the keys of the schema.
+var descriptions = map[string]string{
+	"$schema":             "JSON Schema of the config, lets editors complete and check it",
+	"schemaVersion":       "Version of the config format, upgraded by `versioner config migrate`",
+	"baseBranch":          "Branch changes are merged into",
+	"ignore":              "Patterns of files that do not need a changeset when changed",
+	"commit":              "Commit the changes of a release",
+	"commitMsg":           "Message of the release commit",
+	"amendCommit":         "Amend the last commit with the release instead of making a new one",
+	"nextVersion":         "Version the last release was bumped to",
+	"signKey":             "Path of the armored GPG keyring signing the version tags",
+	"push":                "Push the release commit and tag",
+	"remote":              "Remote the release is pushed to",
+	"sshKey":              "Path of the SSH key used to push",
+	"archive":             "Keep released changesets in a directory or a manifest, empty removes them",
+	"archiveRetention":    "Number of releases to keep archived changesets for, zero keeps all of them",
+	"summaryLimit":        "Number of characters a summary should stay within",
+	"tagTemplate":         "Names the version tags, {version} is replaced by the version",
+	"changelog":           "Path of the changelog relative to the project, CHANGELOG.md when empty",
+	"types":               "Custom conventional types, a type using the key of a built in type replaces it",
+	"types.type":          "Key of the type written in changesets, like feat",
+	"types.title":         "Title of the changelog section",
+	"types.level":         "Level of the version bump",
+	"types.canBeBreaking": "Changes of the type can be marked as breaking",
+	"theme":               "Colors and symbols of the prompts, colors are ANSI numbers or hex codes",
+	"keys":                "Keys of the prompts by the name of the binding, like submit or back",
+}
+
+// enums are the values allowed for keys that only take a few.
+var enums = map[string][]any{
+	"archive":     {"", ArchiveDirectory, ArchiveManifest},
+	"types.level": {"", "major", "minor", "patch", "none"},
+}
+
+// Schema is the JSON Schema of the config, made from the fields of
+// Configuration.
+func Schema() map[string]any {
+	schema := object(reflect.TypeOf(Configuration{}), "")
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaURL
+	schema["title"] = "versioner config"
+
+	return schema
+}
+
+func schemaOf(t reflect.Type, key string) map[string]any {
+	var s map[string]any
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), key)
+	case reflect.Struct:
+		s = object(t, key)
+	case reflect.Slice:
+		// the items of a list share the key of the list
+		s = map[string]any{"type": "array", "items": schemaOf(t.Elem(), key)}
+	case reflect.Map:
+		s = map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), key)}
+	case reflect.Bool:
+		s = map[string]any{"type": "boolean"}
+	case reflect.Int:
+		s = map[string]any{"type": "integer", "minimum": 0}
+	default:
+		s = map[string]any{"type": "string"}
+	}
+
+	if d, ok := descriptions[key]; ok {
+		s["description"] = d
+	}
+
+	if e, ok := enums[key]; ok {
+		s["enum"] = e
+	}
+
+	return s
+}
+
+// object lists the fields of the struct as properties, fields left out when
+// empty are optional.
+func object(t reflect.Type, key string) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := jsonName(f)
+
+		properties[name] = schemaOf(f.Type, join(key, name))
+
+		if !strings.Contains(f.Tag.Get("json"), ",omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	s := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	if len(required) > 0 {
+		s["required"] = required
+	}
+
+	return s
+}
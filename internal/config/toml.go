@@ -0,0 +1,454 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlLayout is what the TOML decoder leaves out of a file, by the path of the
+// key or table it belongs to. Elements of arrays of tables have their index in
+// the path, like types[0].title.
+type tomlLayout struct {
+	order map[string]int
+	head  map[string]string
+	line  map[string]string
+	// indent of the keys in tables, nil before the first key in a table.
+	indent *string
+	// foot are the comments below the last key.
+	foot string
+}
+
+// tomlNode reads TOML into a node with the keys in the order of the file and
+// the comments kept like YAML comments, it returns the indent of the keys in
+// tables too.
+func tomlNode(b []byte) (*yaml.Node, string, error) {
+	values := map[string]any{}
+
+	if _, err := toml.Decode(string(b), &values); err != nil {
+		return nil, "", err
+	}
+
+	layout := scanTOML(string(b))
+
+	n, err := orderedNode(values, "", layout)
+	if err != nil {
+		return nil, "", err
+	}
+
+	n.FootComment = layout.foot
+
+	indent := ""
+	if layout.indent != nil {
+		indent = *layout.indent
+	}
+
+	return n, indent, nil
+}
+
+// scanTOML finds the order, the comments and the indent of the keys line by
+// line.
+func scanTOML(content string) tomlLayout {
+	layout := tomlLayout{
+		order: map[string]int{},
+		head:  map[string]string{},
+		line:  map[string]string{},
+	}
+
+	table := ""
+	// elements counts the elements of each array of tables so far.
+	elements := map[string]int{}
+	pending := []string{}
+	// open and quote are the brackets and multi-line string a value leaves
+	// open on the next lines.
+	open, quote := 0, ""
+
+	for i, l := range strings.Split(content, "\n") {
+		t := strings.TrimSpace(l)
+
+		if open > 0 || len(quote) > 0 {
+			open, quote, _ = scanValue(t, open, quote)
+			continue
+		}
+
+		switch {
+		case len(t) == 0:
+			continue
+		case strings.HasPrefix(t, "#"):
+			pending = append(pending, t)
+			continue
+		case strings.HasPrefix(t, "[["), strings.HasPrefix(t, "["):
+			array := strings.HasPrefix(t, "[[")
+			end := strings.Index(t, "]")
+			if end < 0 {
+				continue
+			}
+
+			parts := splitKey(strings.TrimLeft(t[:end], "["))
+			key := ""
+			for j, part := range parts {
+				key = join(key, part)
+				if j == len(parts)-1 {
+					break
+				}
+
+				if n, ok := elements[key]; ok {
+					key = fmt.Sprintf("%s[%d]", key, n-1)
+				}
+			}
+
+			table = key
+			if array {
+				table = fmt.Sprintf("%s[%d]", key, elements[key])
+				elements[key]++
+			}
+
+			if _, ok := layout.order[key]; !ok {
+				layout.order[key] = i
+			}
+
+			_, _, comment := scanValue(t[end:], 0, "")
+			layout.head[table], layout.line[table] = strings.Join(pending, "\n"), comment
+			pending = nil
+
+			continue
+		}
+
+		k, rest, ok := cutKey(t)
+		if !ok {
+			continue
+		}
+
+		key := table
+		for _, part := range splitKey(k) {
+			key = join(key, part)
+		}
+
+		if _, ok := layout.order[key]; !ok {
+			layout.order[key] = i
+		}
+
+		if len(table) > 0 && layout.indent == nil {
+			indent := l[:len(l)-len(strings.TrimLeft(l, " \t"))]
+			layout.indent = &indent
+		}
+
+		var comment string
+		open, quote, comment = scanValue(rest, 0, "")
+		layout.head[key], layout.line[key] = strings.Join(pending, "\n"), comment
+		pending = nil
+	}
+
+	layout.foot = strings.Join(pending, "\n")
+
+	return layout
+}
+
+// scanValue follows the brackets and strings of a value, it returns what is
+// left open at the end of the line and the comment after the value.
+func scanValue(s string, open int, quote string) (int, string, string) {
+	for i := 0; i < len(s); i++ {
+		if len(quote) > 0 {
+			if quote == `"` && s[i] == '\\' {
+				i++
+				continue
+			}
+
+			if strings.HasPrefix(s[i:], quote) {
+				i += len(quote) - 1
+				quote = ""
+			}
+
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(s[i:], `"""`), strings.HasPrefix(s[i:], `'''`):
+			quote = s[i : i+3]
+			i += 2
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i : i+1]
+		case s[i] == '[' || s[i] == '{':
+			open++
+		case s[i] == ']' || s[i] == '}':
+			open--
+		case s[i] == '#':
+			return open, quote, strings.TrimSpace(s[i:])
+		}
+	}
+
+	// single line strings cannot go on
+	if len(quote) == 1 {
+		quote = ""
+	}
+
+	return open, quote, ""
+}
+
+// cutKey splits a key line at the equal sign outside of quotes.
+func cutKey(s string) (string, string, bool) {
+	quote := byte(0)
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0 && s[i] == quote:
+			quote = 0
+		case quote != 0:
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '=':
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), true
+		}
+	}
+
+	return "", "", false
+}
+
+// splitKey splits a dotted key at the dots outside of quotes and unquotes its
+// parts.
+func splitKey(s string) []string {
+	parts := []string{}
+	quote := byte(0)
+	start := 0
+
+	for i := 0; i <= len(s); i++ {
+		switch {
+		case i == len(s) || quote == 0 && s[i] == '.':
+			parts = append(parts, unquoteKey(strings.TrimSpace(s[start:i])))
+			start = i + 1
+		case quote != 0 && s[i] == quote:
+			quote = 0
+		case quote == 0 && (s[i] == '"' || s[i] == '\''):
+			quote = s[i]
+		}
+	}
+
+	return parts
+}
+
+func unquoteKey(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		var unquoted string
+		if json.Unmarshal([]byte(s), &unquoted) == nil {
+			return unquoted
+		}
+	}
+
+	return strings.Trim(s, "'")
+}
+
+func orderedNode(v any, prefix string, layout tomlLayout) (*yaml.Node, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		kk := make([]string, 0, len(v))
+		for k := range v {
+			kk = append(kk, k)
+		}
+		sort.Strings(kk)
+
+		sort.SliceStable(kk, func(i, j int) bool {
+			return layout.order[join(prefix, kk[i])] < layout.order[join(prefix, kk[j])]
+		})
+
+		m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+		for _, k := range kk {
+			key := join(prefix, k)
+
+			n, err := orderedNode(v[k], key, layout)
+			if err != nil {
+				return nil, err
+			}
+
+			n.LineComment = layout.line[key]
+			m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k, HeadComment: layout.head[key]}, n)
+		}
+
+		return m, nil
+	case []map[string]any:
+		s := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+		for i, e := range v {
+			element := fmt.Sprintf("%s[%d]", prefix, i)
+
+			n, err := orderedNode(e, element, layout)
+			if err != nil {
+				return nil, err
+			}
+
+			n.HeadComment, n.LineComment = layout.head[element], layout.line[element]
+			s.Content = append(s.Content, n)
+		}
+
+		return s, nil
+	case []any:
+		s := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+		for _, e := range v {
+			n, err := orderedNode(e, prefix, layout)
+			if err != nil {
+				return nil, err
+			}
+
+			s.Content = append(s.Content, n)
+		}
+
+		return s, nil
+	}
+
+	return valueNode(v)
+}
+
+// writeTOML writes the keys of the mapping with plain values first, then the
+// tables and the arrays of tables, as TOML needs the tables last. Keys in
+// tables are indented by indent.
+func writeTOML(w io.Writer, m *yaml.Node, prefix, indent string) error {
+	tables := []int{}
+
+	in := ""
+	if len(prefix) > 0 {
+		in = indent
+	}
+
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		v := m.Content[i+1]
+
+		if v.Kind == yaml.MappingNode || isTableArray(v) {
+			tables = append(tables, i)
+			continue
+		}
+
+		value, err := tomlValue(v)
+		if err != nil {
+			return err
+		}
+
+		key, err := tomlKey(m.Content[i].Value)
+		if err != nil {
+			return err
+		}
+
+		writeTOMLLine(w, m.Content[i].HeadComment, in+key+" = "+value, v.LineComment)
+	}
+
+	for _, i := range tables {
+		key, err := tomlKey(m.Content[i].Value)
+		if err != nil {
+			return err
+		}
+
+		name := key
+		if len(prefix) > 0 {
+			name = prefix + "." + key
+		}
+
+		v := m.Content[i+1]
+
+		if v.Kind == yaml.MappingNode {
+			io.WriteString(w, "\n")
+			writeTOMLLine(w, m.Content[i].HeadComment, "["+name+"]", v.LineComment)
+
+			if err = writeTOML(w, v, name, indent); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		for _, e := range v.Content {
+			io.WriteString(w, "\n")
+			writeTOMLLine(w, e.HeadComment, "[["+name+"]]", e.LineComment)
+
+			if err = writeTOML(w, e, name, indent); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeTOMLLine writes a line with the comments above it and after it.
+func writeTOMLLine(w io.Writer, head, line, comment string) {
+	if len(head) > 0 {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		io.WriteString(w, indent+strings.ReplaceAll(head, "\n", "\n"+indent)+"\n")
+	}
+
+	if len(comment) > 0 {
+		line += " " + comment
+	}
+
+	io.WriteString(w, line+"\n")
+}
+func isTableArray(n *yaml.Node) bool {
+	if n.Kind != yaml.SequenceNode || len(n.Content) == 0 {
+		return false
+	}
+
+	for _, e := range n.Content {
+		if e.Kind != yaml.MappingNode {
+			return false
+		}
+	}
+
+	return true
+}
+
+func tomlValue(n *yaml.Node) (string, error) {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		items := make([]string, len(n.Content))
+
+		for i, e := range n.Content {
+			s, err := tomlValue(e)
+			if err != nil {
+				return "", err
+			}
+
+			items[i] = s
+		}
+
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case yaml.MappingNode:
+		pairs := []string{}
+
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, err := tomlKey(n.Content[i].Value)
+			if err != nil {
+				return "", err
+			}
+
+			s, err := tomlValue(n.Content[i+1])
+			if err != nil {
+				return "", err
+			}
+
+			pairs = append(pairs, key+" = "+s)
+		}
+
+		return "{" + strings.Join(pairs, ", ") + "}", nil
+	}
+
+	if n.ShortTag() == "!!null" {
+		return "", fmt.Errorf("TOML has no null values")
+	}
+
+	return scalarText(n)
+}
+
+func tomlKey(key string) (string, error) {
+	if bareKey.MatchString(key) {
+		return key, nil
+	}
+
+	return jsonString(key)
+}
@@ -1,23 +1,70 @@
 package context
 
-import "github.com/go-git/go-git/v5"
+import (
+	"errors"
+	"path"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+var ErrNoGitDir = errors.New("repository is not stored on disk")
 
 type Context struct {
 	repo *git.Repository
+	root string
 	wd   string
 }
 
-func New(repo *git.Repository, wd string) Context {
+// New makes the context of a project at wd, inside the worktree of repo at
+// root. The project is where .versioner is, which is the root unless the
+// repository holds several projects.
+func New(repo *git.Repository, root, wd string) Context {
 	return Context{
 		wd:   wd,
+		root: root,
 		repo: repo,
 	}
 }
 
+// Wd is the directory of the project.
 func (c Context) Wd() string {
 	return c.wd
 }
 
+// Root is the directory of the git worktree.
+func (c Context) Root() string {
+	return c.root
+}
+
 func (c Context) Repo() *git.Repository {
 	return c.repo
 }
+
+// Dir is the project relative to the root in the form git uses for paths,
+// empty when the project is the root.
+func (c Context) Dir() string {
+	rel, err := filepath.Rel(c.root, c.wd)
+	if err != nil || rel == "." {
+		return ""
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// RepoPath turns a path relative to the project into the path git knows it by.
+func (c Context) RepoPath(name string) string {
+	return path.Join(c.Dir(), name)
+}
+
+// StateDir is where the project keeps what is never committed, inside the
+// git directory of the worktree.
+func (c Context) StateDir() (string, error) {
+	s, ok := c.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", ErrNoGitDir
+	}
+
+	return filepath.Join(s.Filesystem().Root(), "versioner", filepath.FromSlash(c.Dir())), nil
+}
@@ -0,0 +1,50 @@
+package detect
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Packages finds the Go modules of a monorepo, the path of each package is
+// relative to wd with "." for the module at the root.
+func Packages(wd string) ([]Project, error) {
+	pp := []Project{}
+
+	err := filepath.WalkDir(wd, func(p string, d fs.DirEntry, e error) error {
+		if e != nil {
+			return e
+		}
+
+		if d.IsDir() && p != wd && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor" || d.Name() == "testdata") {
+			return filepath.SkipDir
+		}
+
+		if d.IsDir() || d.Name() != "go.mod" {
+			return nil
+		}
+
+		dir := path.Dir(p)
+
+		project, err := Golang(dir)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(wd, dir)
+		if err != nil {
+			return err
+		}
+
+		project.Path = filepath.ToSlash(rel)
+		pp = append(pp, project)
+
+		return nil
+	})
+
+	sort.Slice(pp, func(i, j int) bool { return pp[i].Path < pp[j].Path })
+
+	return pp, err
+}
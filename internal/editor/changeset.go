@@ -0,0 +1,139 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"versioner/internal/changeset"
+	"versioner/internal/fsutil"
+
+	"github.com/pkg/errors"
+)
+
+// guidanceStart and guidanceEnd mark the comment put in by Compose, so the
+// comments the user writes in the body are kept.
+const (
+	guidanceStart = "<!-- versioner:guidance"
+	guidanceEnd   = "versioner:guidance -->"
+)
+
+const guidance = guidanceStart + `
+Put the type of the change between the dashes above, one of:
+
+%s
+Add a ! after the type for a breaking change, like feat!.
+In a monorepo the affected packages follow the type, one "path: level" per line.
+The first line below the dashes is the summary that ends up in the changelog,
+the lines after it are kept in the changeset as its body.
+This comment is removed when the changeset is saved.
+` + guidanceEnd + `
+`
+
+var guidanceRe = regexp.MustCompile(`(?s)` + regexp.QuoteMeta(guidanceStart) + `.*?` + regexp.QuoteMeta(guidanceEnd) + `\n?`)
+
+// Compose lets the user write the changeset in a temporary file prefilled
+// with the template. When the result cannot be parsed retry is asked whether
+// to edit again, answering no returns the error and keeps the file around.
+func Compose(c changeset.Changeset, retry func(error) bool) (changeset.Changeset, error) {
+	f, err := os.CreateTemp("", "versioner-*.md")
+	if err != nil {
+		return c, errors.Wrap(err, "could not create changeset file")
+	}
+	f.Close()
+
+	if len(c.Type) == 0 {
+		c.Type = changeset.Types[0].Type
+	}
+
+	content := strings.Replace(c.Markdown(), "---\n\n", "---\n\n"+template(), 1)
+	if err = os.WriteFile(f.Name(), []byte(content), 0o600); err != nil {
+		return c, errors.Wrap(err, "could not create changeset file")
+	}
+
+	for {
+		if err = Open(f.Name()); err != nil {
+			return c, err
+		}
+
+		b, err := os.ReadFile(f.Name())
+		if err != nil {
+			return c, err
+		}
+
+		res, err := parse(string(b), f.Name())
+		if err == nil {
+			os.Remove(f.Name())
+			return res, nil
+		}
+
+		if !retry(err) {
+			return c, errors.Wrapf(err, "changeset is kept in %s", f.Name())
+		}
+	}
+}
+
+// Edit lets the user change a saved changeset in place, checking it like
+// Compose. When the result cannot be parsed retry is asked whether to edit
+// again, answering no writes back the original content and returns the error.
+func Edit(file string, retry func(error) bool) (changeset.Changeset, error) {
+	original, err := os.ReadFile(file)
+	if err != nil {
+		return changeset.Changeset{}, err
+	}
+
+	for {
+		if err = Open(file); err != nil {
+			return changeset.Changeset{}, err
+		}
+
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return changeset.Changeset{}, err
+		}
+
+		res, err := parse(string(b), file)
+		if err == nil {
+			return res, nil
+		}
+
+		if !retry(err) {
+			if werr := fsutil.WriteFile(file, original); werr != nil {
+				return res, errors.Wrapf(err, "could not restore %s: %s", file, werr)
+			}
+
+			return res, errors.Wrapf(err, "changeset is restored to what it was")
+		}
+	}
+}
+
+func template() string {
+	var sb strings.Builder
+
+	for _, t := range changeset.Types {
+		sb.WriteString(fmt.Sprintf("  %-10s %s\n", t.Type, t.Title))
+	}
+
+	return fmt.Sprintf(guidance, sb.String())
+}
+
+func parse(content, file string) (changeset.Changeset, error) {
+	if loc := guidanceRe.FindStringIndex(content); loc != nil {
+		content = content[:loc[0]] + content[loc[1]:]
+	}
+
+	c, err := changeset.Parse(content, file)
+	if err != nil {
+		return c, err
+	}
+
+	if _, err = c.ConventionalType(); err != nil {
+		return c, err
+	}
+
+	if c.Breaking && !changeset.Types.CanBeBreaking(c.Type) {
+		return c, errors.Errorf("%s cannot be breaking", c.Type)
+	}
+
+	return c, nil
+}
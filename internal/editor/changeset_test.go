@@ -0,0 +1,80 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeEditor makes $VISUAL a script writing each of the contents in turn.
+func fakeEditor(t *testing.T, contents ...string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\nn=$(cat " + filepath.Join(dir, "count") + " 2>/dev/null || echo 0)\n" +
+		"cp " + filepath.Join(dir, "content") + "$n \"$1\"\n" +
+		"echo $((n + 1)) > " + filepath.Join(dir, "count") + "\n"
+
+	for i, c := range contents {
+		name := filepath.Join(dir, "content"+string(rune('0'+i)))
+		if err := os.WriteFile(name, []byte(c), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "editor"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("VISUAL", filepath.Join(dir, "editor"))
+}
+
+func changesetFile(t *testing.T, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "some_change.md")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+const original = "---\nfix\n---\n\nFix a thing\n\n"
+
+func TestEditRestoresInvalid(t *testing.T) {
+	file := changesetFile(t, original)
+	fakeEditor(t, "---\nfixx\n---\n\nFix a thing\n\n", "---\nfeat\n---\n\nDocs only\n\n")
+
+	_, err := Edit(file, func(error) bool { return false })
+	if err == nil {
+		t.Fatal("an unknown type was accepted")
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != original {
+		t.Errorf("changeset was not restored:\n%s", b)
+	}
+}
+
+func TestEditRetry(t *testing.T) {
+	file := changesetFile(t, original)
+	fakeEditor(t, "---\ndocs!\n---\n\nFix a thing\n\n", "---\nfeat!\n---\n\nA thing <!-- kept -->\n\n")
+
+	retries := 0
+	c, err := Edit(file, func(error) bool {
+		retries++
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if retries != 1 || c.Type != "feat" || !c.Breaking || c.Summary != "A thing <!-- kept -->" {
+		t.Errorf("got %+v after %d retries", c, retries)
+	}
+}
@@ -0,0 +1,43 @@
+package editor
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const fallback = "vi"
+
+// Command is the editor of the user, $VISUAL before $EDITOR like git does.
+func Command() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if e := strings.TrimSpace(os.Getenv(env)); len(e) > 0 {
+			return e
+		}
+	}
+
+	return fallback
+}
+
+// Cmd is the command running the editor on the file.
+func Cmd(file string) *exec.Cmd {
+	args := strings.Fields(Command())
+	args = append(args, file)
+
+	//nolint:gosec
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd
+}
+
+// Open runs the editor on the file and waits for it to exit.
+func Open(file string) error {
+	cmd := Cmd(file)
+
+	return errors.Wrapf(cmd.Run(), "could not run editor %s", cmd.Args[0])
+}
@@ -0,0 +1,72 @@
+// Package fsutil writes the files of a project so that a crash leaves either
+// the old or the new file behind, never a partial one.
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const (
+	FileMode os.FileMode = 0o644
+	DirMode  os.FileMode = 0o755
+)
+
+// WriteFile replaces the file with data through a temporary file in the same
+// directory, which is synced before it is renamed over the file.
+func WriteFile(name string, data []byte) (err error) {
+	dir := filepath.Dir(name)
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+
+	if err = f.Chmod(FileMode); err != nil {
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(f.Name(), name); err != nil {
+		return err
+	}
+
+	syncDir(dir)
+
+	return nil
+}
+
+// MkdirAll creates the directory and its parents with DirMode.
+func MkdirAll(dir string) error {
+	return os.MkdirAll(dir, DirMode)
+}
+
+// syncDir makes the rename durable, not every platform can sync a directory
+// so it is done on a best effort basis.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+
+	d.Sync()
+}
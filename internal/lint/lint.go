@@ -0,0 +1,261 @@
+package lint
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"versioner/internal/changelog"
+	"versioner/internal/changeset"
+	"versioner/internal/config"
+	"versioner/internal/detect"
+
+	"github.com/pkg/errors"
+)
+
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+type Rule struct {
+	ID          string
+	Description string
+}
+
+var (
+	RuleMalformed          = Rule{"changeset-malformed", "Changeset cannot be parsed"}
+	RuleUnknownType        = Rule{"unknown-type", "Changeset type is not a known conventional type"}
+	RuleBreakingNotAllowed = Rule{"breaking-not-allowed", "Changeset type cannot be breaking"}
+	RuleEmptySummary       = Rule{"empty-summary", "Changeset has no summary"}
+	RuleDuplicateSummary   = Rule{"duplicate-summary", "Changeset summary is already used by another changeset"}
+	RuleStrayFile          = Rule{"stray-file", "Markdown file in .versioner is not a changeset"}
+	RuleConfig             = Rule{"config-invalid", "Config does not match the configuration schema"}
+	RuleChangelog          = Rule{"changelog-invalid", "Changelog cannot be parsed"}
+
+	Rules = []Rule{
+		RuleMalformed,
+		RuleUnknownType,
+		RuleBreakingNotAllowed,
+		RuleEmptySummary,
+		RuleDuplicateSummary,
+		RuleStrayFile,
+		RuleConfig,
+		RuleChangelog,
+	}
+)
+
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s (%s)", d.File, d.Line, d.Severity, d.Message, d.Rule)
+}
+
+type Diagnostics []Diagnostic
+
+func (dd Diagnostics) HasErrors() bool {
+	for _, d := range dd {
+		if d.Severity == Error {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run lints the changesets, the config and the changelog of the project,
+// collecting every problem instead of stopping at the first one.
+func Run(wd string) (Diagnostics, error) {
+	dd := Diagnostics{}
+
+	cc, err := lintChangesets(wd)
+	if err != nil {
+		return dd, err
+	}
+	dd = append(dd, cc...)
+
+	cc, err = lintConfig(wd)
+	if err != nil {
+		return dd, err
+	}
+	dd = append(dd, cc...)
+
+	cc, err = lintChangelog(wd)
+	if err != nil {
+		return dd, err
+	}
+	dd = append(dd, cc...)
+
+	return dd, nil
+}
+
+func lintChangesets(wd string) (Diagnostics, error) {
+	dd := Diagnostics{}
+	summaries := map[string]string{}
+
+	err := filepath.WalkDir(path.Join(wd, config.Dir), func(p string, d fs.DirEntry, e error) error {
+		if e != nil {
+			return e
+		}
+
+		if d.IsDir() && d.Name() == changeset.ReleasedDir {
+			return filepath.SkipDir
+		}
+
+		if filepath.Ext(d.Name()) != ".md" {
+			return nil
+		}
+
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		file := relative(wd, p)
+		content := string(b)
+		typeLine, summaryLine := changesetLines(content)
+
+		if !strings.HasPrefix(content, "---") {
+			dd = append(dd, diagnostic(file, 1, Warning, RuleStrayFile, "file has no changeset front matter"))
+			return nil
+		}
+
+		c, err := changeset.Parse(content, file)
+		if err != nil {
+			if summaryLine == 0 && typeLine > 0 {
+				dd = append(dd, diagnostic(file, typeLine, Error, RuleEmptySummary, "summary cannot be empty"))
+			} else {
+				dd = append(dd, diagnostic(file, 1, Error, RuleMalformed, err.Error()))
+			}
+			return nil
+		}
+
+		if _, err = c.ConventionalType(); errors.Is(err, changeset.ErrConventionalTypeNotFound) {
+			dd = append(dd, diagnostic(file, typeLine, Error, RuleUnknownType, fmt.Sprintf("unknown type %q", c.Type)))
+		} else if c.Breaking && !changeset.Types.CanBeBreaking(c.Type) {
+			dd = append(dd, diagnostic(file, typeLine, Error, RuleBreakingNotAllowed, fmt.Sprintf("type %q cannot be breaking", c.Type)))
+		}
+
+		key := strings.ToLower(strings.TrimSpace(c.Summary))
+		if len(key) == 0 {
+			dd = append(dd, diagnostic(file, summaryLine, Error, RuleEmptySummary, "summary cannot be empty"))
+		} else if other, ok := summaries[key]; ok {
+			dd = append(dd, diagnostic(file, summaryLine, Warning, RuleDuplicateSummary, fmt.Sprintf("summary is the same as in %s", other)))
+		} else {
+			summaries[key] = file
+		}
+
+		return nil
+	})
+
+	return dd, err
+}
+
+// changesetLines finds the line of the type in the front matter and the line
+// of the summary, zero when they are missing.
+func changesetLines(content string) (int, int) {
+	typeLine, summaryLine := 0, 0
+	fences := 0
+
+	for i, l := range strings.Split(content, "\n") {
+		l = strings.TrimSpace(l)
+
+		switch {
+		case l == "---":
+			fences++
+		case len(l) == 0:
+		case fences == 1 && typeLine == 0:
+			typeLine = i + 1
+		case fences >= 2 && summaryLine == 0:
+			summaryLine = i + 1
+		}
+	}
+
+	return typeLine, summaryLine
+}
+
+func lintConfig(wd string) (Diagnostics, error) {
+	dd := Diagnostics{}
+	p, _ := config.RepoFile(wd)
+
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return append(dd, diagnostic(relative(wd, p), 1, Error, RuleConfig, config.NotInitialized.Error())), nil
+	}
+
+	if err != nil {
+		return dd, err
+	}
+
+	for _, problem := range config.ValidateFile(p, b) {
+		dd = append(dd, diagnostic(relative(wd, p), problem.Line, Error, RuleConfig, problem.Message))
+	}
+
+	return dd, nil
+}
+
+func lintChangelog(wd string) (Diagnostics, error) {
+	dd := Diagnostics{}
+
+	// a broken config is reported by lintConfig, the default path is linted
+	// in the meantime
+	conf, err := config.Read(wd)
+	if err != nil {
+		conf = config.Configuration{}
+	}
+
+	p := path.Join(wd, changelog.File(conf))
+
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return dd, nil
+	}
+
+	if err != nil {
+		return dd, err
+	}
+
+	project, err := detect.Run(wd)
+	if err != nil {
+		return dd, err
+	}
+
+	if _, err = changelog.ParseMarkdown(project.Name, string(b)); err != nil {
+		dd = append(dd, diagnostic(relative(wd, p), 1, Error, RuleChangelog, err.Error()))
+	}
+
+	return dd, nil
+}
+
+func diagnostic(file string, line int, severity Severity, rule Rule, msg string) Diagnostic {
+	if line == 0 {
+		line = 1
+	}
+
+	return Diagnostic{
+		File:     file,
+		Line:     line,
+		Severity: severity,
+		Rule:     rule.ID,
+		Message:  msg,
+	}
+}
+
+func relative(wd, p string) string {
+	rel, err := filepath.Rel(wd, p)
+	if err != nil {
+		return p
+	}
+
+	return filepath.ToSlash(rel)
+}
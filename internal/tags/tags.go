@@ -0,0 +1,169 @@
+package tags
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"versioner/internal/config"
+
+	"github.com/Masterminds/semver"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrAlreadyExist  = errors.New("tag already exist")
+	ErrOnOtherCommit = errors.New("tag already exist on a different commit than HEAD")
+)
+
+type Tag struct {
+	Name string
+	// Ref is what the tag reference points at, the tag object for annotated
+	// tags and the commit for lightweight tags.
+	Ref       plumbing.Hash
+	Commit    plumbing.Hash
+	Annotated bool
+	Version   *semver.Version
+}
+
+// Index holds every tag in the repository, annotated and lightweight, with
+// the commit they resolve to.
+type Index struct {
+	repo *git.Repository
+	tags []Tag
+}
+
+// New reads the tags of the repository, template is the tag template of the
+// config that version tags are named by.
+func New(repo *git.Repository, template string) (Index, error) {
+	idx := Index{repo: repo}
+
+	refs, err := repo.Tags()
+	if err != nil {
+		return idx, errors.Wrap(err, "could not list tags")
+	}
+	defer refs.Close()
+
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		t := Tag{
+			Name:   ref.Name().Short(),
+			Ref:    ref.Hash(),
+			Commit: ref.Hash(),
+		}
+
+		obj, err := repo.TagObject(ref.Hash())
+		switch {
+		case err == nil:
+			t.Annotated = true
+			t.Commit = obj.Target
+
+			if c, err := obj.Commit(); err == nil {
+				t.Commit = c.Hash
+			}
+		case !errors.Is(err, plumbing.ErrObjectNotFound):
+			return errors.Wrapf(err, "could not read tag %s", t.Name)
+		}
+
+		t.Version = parseVersion(t.Name, template)
+
+		idx.tags = append(idx.tags, t)
+
+		return nil
+	})
+	if err != nil {
+		return idx, err
+	}
+
+	return idx, nil
+}
+
+// parseVersion reads the version out of a tag named by the template, without
+// template any semver tag counts.
+func parseVersion(name, template string) *semver.Version {
+	if prefix, suffix, ok := strings.Cut(template, config.VersionPlaceholder); ok {
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) || len(name) < len(prefix)+len(suffix) {
+			return nil
+		}
+
+		name = name[len(prefix) : len(name)-len(suffix)]
+	}
+
+	v, err := semver.NewVersion(name)
+	if err != nil {
+		return nil
+	}
+
+	return v
+}
+
+func (i Index) Find(name string) (Tag, bool) {
+	for _, t := range i.tags {
+		if t.Name == name {
+			return t, true
+		}
+	}
+
+	return Tag{}, false
+}
+
+// Versions returns the semver tags ordered from the oldest version to the newest.
+func (i Index) Versions() []Tag {
+	vv := []Tag{}
+
+	for _, t := range i.tags {
+		if t.Version != nil {
+			vv = append(vv, t)
+		}
+	}
+
+	sort.SliceStable(vv, func(a, b int) bool {
+		return vv[a].Version.LessThan(vv[b].Version)
+	})
+
+	return vv
+}
+
+// Latest walks the history from HEAD and returns the first version tag found,
+// a tag without name and version 0.0.0 is returned when there is none.
+func (i Index) Latest() (Tag, error) {
+	byCommit := map[plumbing.Hash]Tag{}
+
+	for _, t := range i.Versions() {
+		byCommit[t.Commit] = t
+	}
+
+	iter, err := i.repo.Log(&git.LogOptions{})
+	if err != nil {
+		return Tag{}, err
+	}
+	defer iter.Close()
+
+	for c, err := iter.Next(); err == nil; c, err = iter.Next() {
+		if t, found := byCommit[c.Hash]; found {
+			return t, nil
+		}
+	}
+
+	version, err := semver.NewVersion("0.0.0")
+	if err != nil {
+		return Tag{}, err
+	}
+
+	return Tag{Version: version}, nil
+}
+
+// Check fails when the tag is taken, telling apart a tag that already points
+// at the commit from one that points somewhere else.
+func (i Index) Check(name string, commit plumbing.Hash) error {
+	t, ok := i.Find(name)
+	if !ok {
+		return nil
+	}
+
+	if t.Commit == commit {
+		return errors.Wrap(ErrAlreadyExist, name)
+	}
+
+	return errors.Wrap(ErrOnOtherCommit, fmt.Sprintf("%s points at %s", name, t.Commit.String()[:7]))
+}
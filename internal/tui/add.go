@@ -1,13 +1,21 @@
 package tui
 
 import (
+	"fmt"
+	"strings"
+	"versioner/internal/changelog"
 	"versioner/internal/changeset"
+	"versioner/internal/config"
 	"versioner/internal/detect"
+	"versioner/internal/tui/bump"
 	"versioner/internal/tui/choose"
 	"versioner/internal/tui/confirm"
+	"versioner/internal/tui/theme"
 	"versioner/internal/tui/write"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/pkg/errors"
 )
 
@@ -16,35 +24,148 @@ type view int
 const (
 	convType view = iota
 	breaking
+	pkgs
+	bumps
 	summary
+	review
 	done
 )
 
+const (
+	reviewSave         = "Save"
+	reviewEditType     = "Edit type"
+	reviewEditBreaking = "Edit breaking"
+	reviewEditPackages = "Edit packages"
+	reviewEditSummary  = "Edit summary"
+	reviewCancel       = "Cancel"
+)
+
+// ErrSwitchToEditor is returned together with what has been entered so far
+// when the user asks to continue in their editor.
+var ErrSwitchToEditor = errors.New("continue in editor")
+
+// bindings is implemented by the components to fill the help footer.
+type bindings interface {
+	Bindings() []key.Binding
+}
+
 type mainModel struct {
 	state            view
 	conventionalType tea.Model
 	breaking         tea.Model
+	packages         tea.Model
+	bump             tea.Model
 	summary          tea.Model
+	review           tea.Model
 	result           changeset.Changeset
 	err              error
+	summaryErr       string
+	reviewing        bool
 	aborting         bool
+	keys             theme.KeyMap
+
+	// styles
+	footerStyle  lipgloss.Style
+	errorStyle   lipgloss.Style
+	titleStyle   lipgloss.Style
+	previewStyle lipgloss.Style
 }
 
-func NewAddProgram(project detect.Project) (changeset.Changeset, bool, error) {
-	items := make([]string, len(changeset.Types))
+// NewAddProgram asks for a new changeset, packages are only asked for when
+// there is more than one and the changed ones are selected up front.
+func NewAddProgram(project detect.Project, conf config.Configuration, packages []detect.Project, changed []string) (changeset.Changeset, bool, error) {
+	model := newMainModel(conf, packages)
 
-	for i, t := range changeset.Types {
-		items[i] = t.Type
+	if model.packages != nil {
+		model.packages = model.packages.(choose.Model).SetSelected(changed)
+	}
+
+	return run(model)
+}
+
+// NewEditProgram runs the same steps as NewAddProgram with every step
+// prefilled from an existing changeset.
+func NewEditProgram(project detect.Project, conf config.Configuration, packages []detect.Project, c changeset.Changeset) (changeset.Changeset, bool, error) {
+	text := c.Summary
+	if len(c.Body) > 0 {
+		text += "\n\n" + c.Body
+	}
+
+	model := newMainModel(conf, packages)
+	model.result.Packages = c.Packages
+	model.conventionalType = model.conventionalType.(choose.Model).SetCursor(c.Type)
+	model.breaking = model.breaking.(confirm.Model).SetConfirmation(c.Breaking)
+	model.summary = model.summary.(write.Model).SetValue(text)
+
+	if model.packages != nil {
+		paths := make([]string, len(c.Packages))
+		for i, p := range c.Packages {
+			paths[i] = p.Path
+		}
+
+		model.packages = model.packages.(choose.Model).SetSelected(paths)
 	}
 
-	model := mainModel{
+	return run(model)
+}
+
+func newMainModel(conf config.Configuration, packages []detect.Project) mainModel {
+	t := theme.Current()
+
+	m := mainModel{
+		keys:             theme.Keys(),
+		footerStyle:      lipgloss.NewStyle().MarginTop(1),
+		errorStyle:       lipgloss.NewStyle().Foreground(t.Error),
+		titleStyle:       lipgloss.NewStyle().Bold(true).MarginBottom(1),
+		previewStyle:     lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(t.Subdued).Padding(0, 1),
 		state:            convType,
 		result:           changeset.Changeset{},
-		conventionalType: choose.New(items),
+		conventionalType: typeChooser(),
 		breaking:         confirm.New("Are your change/changes breaking?"),
-		summary:          write.New("Summary of this change"),
+		summary:          write.New("Summary of this change, details go on the lines below").SetLimit(conf.SummaryLimit),
+	}
+
+	if len(packages) > 1 {
+		paths := make([]string, len(packages))
+		names := make([]string, len(packages))
+
+		for i, p := range packages {
+			paths[i], names[i] = p.Path, p.Name
+		}
+
+		m.packages = choose.New(paths).SetDescriptions(names).Multiple()
+	}
+
+	return m
+}
+
+// typeChooser lists the conventional types with their title and bump.
+func typeChooser() choose.Model {
+	items := make([]string, len(changeset.Types))
+	descriptions := make([]string, len(changeset.Types))
+
+	for i, t := range changeset.Types {
+		items[i] = t.Type
+		descriptions[i] = fmt.Sprintf("%s · %s", t.Title, levelText(t))
+	}
+
+	return choose.New(items).SetDescriptions(descriptions).Filterable()
+}
+
+func levelText(t changeset.ConventionalType) string {
+	level := t.Level
+	if level == changeset.None {
+		level = "no bump"
 	}
 
+	if t.CanBeBreaking && t.Level != changeset.Major {
+		level += ", major when breaking"
+	}
+
+	return level
+}
+
+func run(model mainModel) (changeset.Changeset, bool, error) {
 	p := tea.NewProgram(model, tea.WithAltScreen())
 	result, err := p.Run()
 	if err != nil {
@@ -73,11 +194,21 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlC:
+		switch {
+		case key.Matches(msg, m.keys.Quit):
 			m.aborting = true
 			return m, tea.Quit
+		case key.Matches(msg, m.keys.Editor):
+			if wm, ok := m.summary.(write.Model); ok {
+				m.result.Summary, m.result.Body = changeset.SplitSummary(wm.Value())
+			}
+			m.err = ErrSwitchToEditor
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Back):
+			return m.back(), nil
 		}
+
+		m.summaryErr = ""
 	}
 
 	switch m.state {
@@ -90,18 +221,19 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
-		if ok && cm.Done() {
+		m.conventionalType = ct
+		cmd = c
+
+		if cm.Done() {
 			m.result.Type = cm.Selected()
 
-			if changeset.Types.CanBeBreaking(cm.Selected()) {
-				m.state = breaking
-			} else {
-				m.state = summary
+			if !changeset.Types.CanBeBreaking(cm.Selected()) {
+				m.result.Breaking = false
+				return m.next(m.afterBreaking()), nil
 			}
-		}
 
-		m.conventionalType = ct
-		cmd = c
+			return m.next(breaking), nil
+		}
 
 	case breaking:
 		b, c := m.breaking.Update(msg)
@@ -112,37 +244,103 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
-		if ok && bm.Done() {
+		m.breaking = b
+		cmd = c
+
+		if bm.Done() {
 			m.result.Breaking = bm.Confirmation()
-			m.state = summary
+			return m.next(m.afterBreaking()), nil
 		}
 
-		m.breaking = b
+	case pkgs:
+		p, c := m.packages.Update(msg)
+
+		pm, ok := p.(choose.Model)
+		if !ok {
+			m.err = errors.New("could not assert Choose Model")
+			return m, tea.Quit
+		}
+
+		m.packages = p
+		cmd = c
+
+		if pm.Done() {
+			m.result.Packages = selectedPackages(m.result, pm.Selections())
+			return m.enter(bumps), nil
+		}
+
+	case bumps:
+		b, c := m.bump.Update(msg)
+
+		bm, ok := b.(bump.Model)
+		if !ok {
+			m.err = errors.New("could not assert Bump Model")
+			return m, tea.Quit
+		}
+
+		m.bump = b
 		cmd = c
 
+		if bm.Done() {
+			m.result.Packages = bm.Packages()
+			return m.next(summary), nil
+		}
+
 	case summary:
 		s, c := m.summary.Update(msg)
 
-		bm, ok := s.(write.Model)
+		wm, ok := s.(write.Model)
 		if !ok {
 			m.err = errors.New("could not assert Write Model")
 			return m, tea.Quit
 		}
 
-		if ok && bm.Done() {
+		m.summary = s
+		cmd = c
 
-			m.result.Summary = bm.Value()
+		if wm.Done() {
+			sum, body := changeset.SplitSummary(wm.Value())
 
-			if len(m.result.Summary) == 0 {
-				m.err = errors.New("summary cannot be empty")
-				return m, tea.Quit
+			if len(sum) == 0 {
+				m.summaryErr = "summary cannot be empty"
+				m.summary = wm.Reset()
+				return m, nil
 			}
 
+			m.result.Summary, m.result.Body = sum, body
+			return m.next(review), nil
+		}
+
+	case review:
+		r, c := m.review.Update(msg)
+
+		rm, ok := r.(choose.Model)
+		if !ok {
+			m.err = errors.New("could not assert Choose Model")
 			return m, tea.Quit
 		}
 
-		m.summary = s
+		m.review = r
 		cmd = c
+
+		if rm.Done() {
+			switch rm.Selected() {
+			case reviewSave:
+				m.state = done
+				return m, tea.Quit
+			case reviewEditType:
+				return m.enter(convType), nil
+			case reviewEditBreaking:
+				return m.enter(breaking), nil
+			case reviewEditPackages:
+				return m.enter(pkgs), nil
+			case reviewEditSummary:
+				return m.enter(summary), nil
+			case reviewCancel:
+				m.aborting = true
+				return m, tea.Quit
+			}
+		}
 	}
 
 	cmds = append(cmds, cmd)
@@ -150,15 +348,175 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// next moves on to the step after the one that was just answered, a field
+// edited from the review goes straight back to the review.
+func (m mainModel) next(state view) mainModel {
+	if m.reviewing {
+		return m.enter(review)
+	}
+
+	return m.enter(state)
+}
+
+// back steps back to the previous step, keeping what was answered so far.
+func (m mainModel) back() mainModel {
+	if m.reviewing && m.state != review {
+		return m.enter(review)
+	}
+
+	switch m.state {
+	case breaking:
+		return m.enter(convType)
+	case pkgs:
+		return m.enter(m.beforePackages())
+	case bumps:
+		return m.enter(pkgs)
+	case summary:
+		if m.packages != nil {
+			return m.enter(bumps)
+		}
+		return m.enter(m.beforePackages())
+	case review:
+		m.reviewing = false
+		return m.enter(summary)
+	}
+
+	return m
+}
+
+// afterBreaking is the step after the type and breaking questions, packages
+// are only asked for in a monorepo.
+func (m mainModel) afterBreaking() view {
+	if m.packages != nil {
+		return pkgs
+	}
+
+	return summary
+}
+
+func (m mainModel) beforePackages() view {
+	if changeset.Types.CanBeBreaking(m.result.Type) {
+		return breaking
+	}
+
+	return convType
+}
+
+// selectedPackages keeps the level of packages that were already selected,
+// newly selected packages are bumped by the level of the changeset.
+func selectedPackages(c changeset.Changeset, paths []string) []changeset.Package {
+	level := changeset.Patch
+	if ct, err := c.ConventionalType(); err == nil {
+		level = ct.Level
+	}
+
+	if c.Breaking {
+		level = changeset.Major
+	}
+
+	pp := []changeset.Package{}
+
+	for _, p := range paths {
+		pkg := changeset.Package{Path: p, Level: level}
+
+		for _, known := range c.Packages {
+			if known.Path == p {
+				pkg = known
+			}
+		}
+
+		pp = append(pp, pkg)
+	}
+
+	return pp
+}
+
+func (m mainModel) enter(state view) mainModel {
+	switch state {
+	case convType:
+		m.conventionalType = m.conventionalType.(choose.Model).Reset()
+	case breaking:
+		m.breaking = m.breaking.(confirm.Model).Reset()
+	case pkgs:
+		m.packages = m.packages.(choose.Model).Reset()
+	case bumps:
+		m.bump = bump.New("How much is each package bumped?", m.result.Packages)
+	case summary:
+		m.summary = m.summary.(write.Model).Reset()
+	case review:
+		m.reviewing = true
+		m.review = choose.New(m.reviewOptions())
+	}
+
+	m.summaryErr = ""
+	m.state = state
+
+	return m
+}
+
+func (m mainModel) reviewOptions() []string {
+	options := []string{reviewSave, reviewEditType}
+
+	if changeset.Types.CanBeBreaking(m.result.Type) {
+		options = append(options, reviewEditBreaking)
+	}
+
+	if m.packages != nil {
+		options = append(options, reviewEditPackages)
+	}
+
+	return append(options, reviewEditSummary, reviewCancel)
+}
+
 func (m mainModel) View() string {
+	current := map[view]tea.Model{
+		convType: m.conventionalType,
+		breaking: m.breaking,
+		pkgs:     m.packages,
+		bumps:    m.bump,
+		summary:  m.summary,
+		review:   m.review,
+	}[m.state]
+
+	keys := []key.Binding{}
+	if b, ok := current.(bindings); ok {
+		keys = b.Bindings()
+	}
+
+	hint := m.footerStyle.Render(theme.Help(append(keys, m.keys.Back, m.keys.Editor, m.keys.Quit)...))
+
 	switch m.state {
 	case convType:
-		return m.conventionalType.View()
+		return lipgloss.JoinVertical(lipgloss.Left, m.conventionalType.View(), hint)
 	case breaking:
-		return m.breaking.View()
+		return lipgloss.JoinVertical(lipgloss.Left, m.breaking.View(), hint)
+	case pkgs:
+		return lipgloss.JoinVertical(lipgloss.Left, m.packages.View(), hint)
+	case bumps:
+		return lipgloss.JoinVertical(lipgloss.Left, m.bump.View(), hint)
 	case summary:
-		return m.summary.View()
+		if len(m.summaryErr) > 0 {
+			return lipgloss.JoinVertical(lipgloss.Left, m.summary.View(), m.errorStyle.Render(m.summaryErr), hint)
+		}
+		return lipgloss.JoinVertical(lipgloss.Left, m.summary.View(), hint)
+	case review:
+		return lipgloss.JoinVertical(lipgloss.Left, m.reviewView(), m.review.View(), hint)
 	}
 
 	return ""
 }
+
+// reviewView shows the changeset file as it is saved next to how it ends up
+// in the changelog.
+func (m mainModel) reviewView() string {
+	file := m.previewStyle.Render(strings.TrimSpace(m.result.Markdown()))
+
+	entry := m.errorStyle.Render("unknown type, the changeset cannot be released")
+	if title, err := changelog.SectionTitle(m.result); err == nil {
+		entry = fmt.Sprintf("### %s\n\n%s", title, m.result.Summary)
+	}
+
+	preview := lipgloss.JoinHorizontal(lipgloss.Top, file, "  ", m.previewStyle.Render(entry))
+
+	return lipgloss.JoinVertical(lipgloss.Left, m.titleStyle.Render("Review the changeset"), preview, "")
+}
@@ -0,0 +1,142 @@
+package bump
+
+import (
+	"fmt"
+	"strings"
+	"versioner/internal/changeset"
+	"versioner/internal/tui/theme"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var levels = []string{changeset.Major, changeset.Minor, changeset.Patch, changeset.None}
+
+type Model struct {
+	packages []changeset.Package
+	index    int
+	done     bool
+	header   string
+	cursor   string
+	keys     theme.KeyMap
+
+	// styles
+	cursorStyle lipgloss.Style
+	headerStyle lipgloss.Style
+	levelStyle  lipgloss.Style
+}
+
+// New lists the packages with the level each is bumped, left and right change
+// the level of the package under the cursor.
+func New(header string, packages []changeset.Package) Model {
+	t := theme.Current()
+
+	return Model{
+		packages:    append([]changeset.Package{}, packages...),
+		header:      header,
+		cursor:      t.Cursor,
+		keys:        theme.Keys(),
+		cursorStyle: lipgloss.NewStyle().Foreground(t.Accent),
+		headerStyle: lipgloss.NewStyle().Foreground(t.Subdued),
+		levelStyle:  lipgloss.NewStyle().Bold(true),
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || len(m.packages) == 0 {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Down):
+		m.index = (m.index + 1) % len(m.packages)
+	case key.Matches(keyMsg, m.keys.Up):
+		m.index = (m.index - 1 + len(m.packages)) % len(m.packages)
+	case key.Matches(keyMsg, m.keys.Right):
+		m.packages = m.withLevel(1)
+	case key.Matches(keyMsg, m.keys.Left):
+		m.packages = m.withLevel(-1)
+	case key.Matches(keyMsg, m.keys.Select):
+		m.done = true
+	}
+
+	return m, nil
+}
+
+func (m Model) withLevel(step int) []changeset.Package {
+	pp := append([]changeset.Package{}, m.packages...)
+
+	current := 0
+	for i, l := range levels {
+		if l == pp[m.index].Level {
+			current = i
+		}
+	}
+
+	pp[m.index].Level = levels[(current+step+len(levels))%len(levels)]
+
+	return pp
+}
+
+func (m Model) View() string {
+	if m.done {
+		return ""
+	}
+
+	var s strings.Builder
+
+	width := 0
+	for _, p := range m.packages {
+		width = max(width, lipgloss.Width(p.Path))
+	}
+
+	for i, p := range m.packages {
+		level := p.Level
+		if level == changeset.None {
+			level = "none"
+		}
+
+		line := fmt.Sprintf("%-*s  ‹ %s ›", width, p.Path, m.levelStyle.Render(level))
+
+		if i == m.index {
+			s.WriteString(m.cursorStyle.Render(m.cursor+" ") + line + "\n")
+		} else {
+			s.WriteString(strings.Repeat(" ", lipgloss.Width(m.cursor)+1) + line + "\n")
+		}
+	}
+
+	if m.header != "" {
+		return lipgloss.JoinVertical(lipgloss.Left, m.headerStyle.Render(m.header), s.String())
+	}
+
+	return s.String()
+}
+
+// Reset lets the levels be changed again, keeping the levels set so far.
+func (m Model) Reset() Model {
+	m.done = false
+	return m
+}
+
+func (m Model) Packages() []changeset.Package {
+	return m.packages
+}
+
+// Bindings are the keys shown in the help footer.
+func (m Model) Bindings() []key.Binding {
+	left, right := m.keys.Left, m.keys.Right
+	left.SetHelp(left.Help().Key, "lower bump")
+	right.SetHelp(right.Help().Key, "higher bump")
+
+	return []key.Binding{m.keys.Up, m.keys.Down, left, right, m.keys.Select}
+}
+
+func (m Model) Done() bool {
+	return m.done
+}
@@ -3,21 +3,23 @@ package choose
 
 import (
 	"strings"
+	"versioner/internal/tui/theme"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/paginator"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-)
-
-var (
-	subduedStyle     = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#847A85", Dark: "#979797"})
-	verySubduedStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#DDDADA", Dark: "#3C3C3C"})
+	"github.com/sahilm/fuzzy"
 )
 
 type Model struct {
 	height           int
 	cursor           string
 	items            []item
+	matches          []match
+	filterable       bool
+	filter           string
+	multiple         bool
 	done             bool
 	index            int
 	paginator        paginator.Model
@@ -25,32 +27,48 @@ type Model struct {
 	unselectedPrefix string
 	cursorPrefix     string
 	header           string
+	keys             theme.KeyMap
 
 	// styles
 	cursorStyle       lipgloss.Style
 	headerStyle       lipgloss.Style
 	itemStyle         lipgloss.Style
 	selectedItemStyle lipgloss.Style
+	subduedStyle      lipgloss.Style
+	matchStyle        lipgloss.Style
 }
 
 type item struct {
-	text     string
-	selected bool
-	order    int
+	text        string
+	description string
+	selected    bool
+	order       int
+}
+
+// match is an item shown with the current filter, matched holds the byte
+// offsets of the matched characters in the text followed by the description.
+type match struct {
+	item    int
+	matched []int
 }
 
 func New(options []string) Model {
+	t := theme.Current()
+
 	m := Model{
 		height:            10,
-		cursor:            ">",
-		cursorPrefix:      "○ ",
-		selectedPrefix:    "◉ ",
-		unselectedPrefix:  "○ ",
+		cursor:            t.Cursor,
+		cursorPrefix:      t.UnselectedPrefix,
+		selectedPrefix:    t.SelectedPrefix,
+		unselectedPrefix:  t.UnselectedPrefix,
 		header:            "",
-		cursorStyle:       lipgloss.NewStyle().Foreground(lipgloss.Color("212")),
-		headerStyle:       lipgloss.NewStyle().Foreground(lipgloss.Color("240")),
+		keys:              theme.Keys(),
+		cursorStyle:       lipgloss.NewStyle().Foreground(t.Accent),
+		headerStyle:       lipgloss.NewStyle().Foreground(t.Subdued),
 		itemStyle:         lipgloss.NewStyle(),
-		selectedItemStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("212")),
+		selectedItemStyle: lipgloss.NewStyle().Foreground(t.Accent),
+		subduedStyle:      lipgloss.NewStyle().Foreground(t.Subdued),
+		matchStyle:        lipgloss.NewStyle().Foreground(t.Accent).Underline(true),
 	}
 
 	items := make([]item, len(options))
@@ -62,16 +80,45 @@ func New(options []string) Model {
 	m.items = items
 
 	pager := paginator.New()
-	pager.SetTotalPages((len(items) + m.height - 1) / m.height)
 	pager.PerPage = m.height
 	pager.Type = paginator.Dots
-	pager.ActiveDot = subduedStyle.Render("•")
-	pager.InactiveDot = verySubduedStyle.Render("•")
+	pager.ActiveDot = m.subduedStyle.Render("•")
+	pager.InactiveDot = lipgloss.NewStyle().Foreground(t.Inactive).Render("•")
 	pager.KeyMap = paginator.KeyMap{}
-	pager.Page = 1 / m.height
 
 	m.paginator = pager
 
+	return m.applyFilter()
+}
+
+// SetDescriptions shows a description next to each option, in the same order
+// as the options.
+func (m Model) SetDescriptions(descriptions []string) Model {
+	items := make([]item, len(m.items))
+
+	for i, it := range m.items {
+		if i < len(descriptions) {
+			it.description = descriptions[i]
+		}
+		items[i] = it
+	}
+
+	m.items = items
+
+	return m
+}
+
+// Multiple lets more than one option be selected, space toggles the option
+// under the cursor.
+func (m Model) Multiple() Model {
+	m.multiple = true
+	return m
+}
+
+// Filterable lets the options be filtered by typing, the options are then
+// fuzzy matched against both their text and description.
+func (m Model) Filterable() Model {
+	m.filterable = true
 	return m
 }
 
@@ -84,41 +131,68 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		return m, nil
 	case tea.KeyMsg:
-		start, end := m.paginator.GetSliceBounds(len(m.items))
-		switch keypress := msg.String(); keypress {
-		case "down", "j", "ctrl+j", "ctrl+n":
+		if m.multiple && key.Matches(msg, m.keys.Toggle) {
+			if len(m.matches) > 0 {
+				i := m.matches[m.index].item
+				m.items = m.toggled(i)
+			}
+			return m, nil
+		}
+
+		if m.filterable {
+			switch msg.Type {
+			case tea.KeyRunes, tea.KeySpace:
+				m.filter += string(msg.Runes)
+				return m.applyFilter(), nil
+			case tea.KeyBackspace:
+				if len(m.filter) > 0 {
+					r := []rune(m.filter)
+					m.filter = string(r[:len(r)-1])
+				}
+				return m.applyFilter(), nil
+			}
+		}
+
+		start, end := m.paginator.GetSliceBounds(len(m.matches))
+		switch {
+		case key.Matches(msg, m.keys.Down):
 			m.index++
-			if m.index >= len(m.items) {
+			if m.index >= len(m.matches) {
 				m.index = 0
 				m.paginator.Page = 0
 			}
 			if m.index >= end {
 				m.paginator.NextPage()
 			}
-		case "up", "k", "ctrl+k", "ctrl+p":
+		case key.Matches(msg, m.keys.Up):
 			m.index--
 			if m.index < 0 {
-				m.index = len(m.items) - 1
+				m.index = len(m.matches) - 1
 				m.paginator.Page = m.paginator.TotalPages - 1
 			}
 			if m.index < start {
 				m.paginator.PrevPage()
 			}
-		case "right", "l", "ctrl+f":
-			m.index = clamp(m.index+m.height, 0, len(m.items)-1)
+		case key.Matches(msg, m.keys.Right):
+			m.index = clamp(m.index+m.height, 0, len(m.matches)-1)
 			m.paginator.NextPage()
-		case "left", "h", "ctrl+b":
-			m.index = clamp(m.index-m.height, 0, len(m.items)-1)
+		case key.Matches(msg, m.keys.Left):
+			m.index = clamp(m.index-m.height, 0, len(m.matches)-1)
 			m.paginator.PrevPage()
-		case "G", "end":
-			m.index = len(m.items) - 1
+		case key.Matches(msg, m.keys.Last):
+			m.index = len(m.matches) - 1
 			m.paginator.Page = m.paginator.TotalPages - 1
-		case "g", "home":
+		case key.Matches(msg, m.keys.First):
 			m.index = 0
 			m.paginator.Page = 0
-		case "enter":
+		case key.Matches(msg, m.keys.Select):
+			if len(m.matches) == 0 {
+				return m, nil
+			}
 			m.done = true
-			m.items[m.index].selected = true
+			if !m.multiple || len(m.Selections()) == 0 {
+				m.items[m.matches[m.index].item].selected = true
+			}
 			return m, nil
 		}
 	}
@@ -135,28 +209,51 @@ func (m Model) View() string {
 
 	var s strings.Builder
 
-	start, end := m.paginator.GetSliceBounds(len(m.items))
-	for i, item := range m.items[start:end] {
+	if m.filterable {
+		s.WriteString(m.headerStyle.Render("Filter: ") + m.filter + "\n")
+	}
+
+	width := 0
+	for _, it := range m.items {
+		width = max(width, lipgloss.Width(it.text))
+	}
+
+	start, end := m.paginator.GetSliceBounds(len(m.matches))
+	for i, mt := range m.matches[start:end] {
+		item := m.items[mt.item]
+
 		if i == m.index%m.height {
 			s.WriteString(m.cursorStyle.Render(m.cursor))
 		} else {
 			s.WriteString(strings.Repeat(" ", lipgloss.Width(m.cursor)))
 		}
 
+		style, prefix := m.itemStyle, m.unselectedPrefix
 		if item.selected {
-			s.WriteString(m.selectedItemStyle.Render(m.selectedPrefix + item.text))
+			style, prefix = m.selectedItemStyle, m.selectedPrefix
 		} else if i == m.index%m.height {
-			s.WriteString(m.cursorStyle.Render(m.cursorPrefix + item.text))
-		} else {
-			s.WriteString(m.itemStyle.Render(m.unselectedPrefix + item.text))
+			style, prefix = m.cursorStyle, m.cursorPrefix
 		}
+
+		s.WriteString(style.Render(prefix))
+		s.WriteString(m.highlight(item.text, mt.matched, 0, style))
+
+		if len(item.description) > 0 {
+			s.WriteString(strings.Repeat(" ", width-lipgloss.Width(item.text)+2))
+			s.WriteString(m.highlight(item.description, mt.matched, len(item.text)+1, m.subduedStyle))
+		}
+
 		if i != m.height {
 			s.WriteRune('\n')
 		}
 	}
 
+	if len(m.matches) == 0 {
+		s.WriteString(m.subduedStyle.Render("  No options match the filter") + "\n")
+	}
+
 	if m.paginator.TotalPages > 1 {
-		s.WriteString(strings.Repeat("\n", m.height-m.paginator.ItemsOnPage(len(m.items))+1))
+		s.WriteString(strings.Repeat("\n", m.height-m.paginator.ItemsOnPage(len(m.matches))+1))
 		s.WriteString("  " + m.paginator.View())
 	}
 
@@ -168,6 +265,103 @@ func (m Model) View() string {
 	return s.String()
 }
 
+// highlight renders text with the matched characters marked, offset is where
+// the text starts in the string that was matched.
+func (m Model) highlight(text string, matched []int, offset int, style lipgloss.Style) string {
+	var s strings.Builder
+
+	marked := map[int]bool{}
+	for _, i := range matched {
+		marked[i-offset] = true
+	}
+
+	run, runMarked := "", false
+	for i, r := range text {
+		if marked[i] != runMarked && len(run) > 0 {
+			s.WriteString(m.renderRun(run, runMarked, style))
+			run = ""
+		}
+
+		run += string(r)
+		runMarked = marked[i]
+	}
+
+	s.WriteString(m.renderRun(run, runMarked, style))
+
+	return s.String()
+}
+
+func (m Model) renderRun(run string, marked bool, style lipgloss.Style) string {
+	if len(run) == 0 {
+		return ""
+	}
+
+	if marked {
+		return m.matchStyle.Render(run)
+	}
+
+	return style.Render(run)
+}
+
+// applyFilter matches the options against the filter, showing every option
+// in its original order when there is no filter.
+func (m Model) applyFilter() Model {
+	m.matches = []match{}
+
+	if len(m.filter) == 0 {
+		for i := range m.items {
+			m.matches = append(m.matches, match{item: i})
+		}
+	} else {
+		data := make([]string, len(m.items))
+		for i, it := range m.items {
+			data[i] = it.text + " " + it.description
+		}
+
+		for _, fm := range fuzzy.Find(m.filter, data) {
+			m.matches = append(m.matches, match{item: fm.Index, matched: fm.MatchedIndexes})
+		}
+	}
+
+	m.index = 0
+	m.paginator.Page = 0
+	m.paginator.SetTotalPages(max(len(m.matches), 1))
+
+	return m
+}
+
+// SetCursor moves the cursor to the option, used to preselect a value.
+func (m Model) SetCursor(option string) Model {
+	for i, mt := range m.matches {
+		if m.items[mt.item].text == option {
+			m.index = i
+			m.paginator.Page = i / m.height
+		}
+	}
+
+	return m
+}
+
+// Reset clears the selection so the option can be chosen again, the cursor
+// stays where it was. Selections of a multiple choice are kept to be changed.
+func (m Model) Reset() Model {
+	if m.multiple {
+		m.done = false
+		return m
+	}
+
+	items := make([]item, len(m.items))
+	for i, it := range m.items {
+		it.selected = false
+		items[i] = it
+	}
+
+	m.items = items
+	m.done = false
+
+	return m
+}
+
 func (m Model) Selected() string {
 	selected := ""
 	for _, opt := range m.items {
@@ -180,6 +374,83 @@ func (m Model) Selected() string {
 	return selected
 }
 
+// SetSelected selects the options, used to preselect a multiple choice.
+func (m Model) SetSelected(options []string) Model {
+	for i, it := range m.items {
+		for _, opt := range options {
+			if it.text == opt && !it.selected {
+				m.items = m.toggled(i)
+			}
+		}
+	}
+
+	return m
+}
+
+// Selections are all selected options in the order they are listed.
+func (m Model) Selections() []string {
+	selected := []string{}
+	for _, opt := range m.items {
+		if opt.selected {
+			selected = append(selected, opt.text)
+		}
+	}
+
+	return selected
+}
+
+// toggled copies the items with the selection of one of them flipped, models
+// are passed by value so the items cannot be changed in place.
+func (m Model) toggled(i int) []item {
+	items := make([]item, len(m.items))
+	copy(items, m.items)
+	items[i].selected = !items[i].selected
+
+	return items
+}
+
+// Bindings are the keys shown in the help footer, typing goes to the filter of
+// a filterable chooser so only the keys that still move are shown then.
+func (m Model) Bindings() []key.Binding {
+	up, down := m.keys.Up, m.keys.Down
+	if m.filterable {
+		up, down = untyped(up), untyped(down)
+	}
+
+	if m.multiple {
+		return []key.Binding{up, down, m.keys.Toggle, m.keys.Select}
+	}
+
+	return []key.Binding{up, down, m.keys.Select}
+}
+
+// untyped leaves out the keys of the binding that type a character, like the
+// k of ↑/k.
+func untyped(b key.Binding) key.Binding {
+	keys := []string{}
+	typed := map[string]bool{}
+
+	for _, k := range b.Keys() {
+		if len([]rune(k)) == 1 {
+			typed[k] = true
+			continue
+		}
+
+		keys = append(keys, k)
+	}
+
+	if len(keys) == 0 {
+		return key.NewBinding(key.WithDisabled())
+	}
+
+	help, _, _ := strings.Cut(b.Help().Key, "/")
+	if typed[help] {
+		help = keys[0]
+	}
+
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(help, b.Help().Desc))
+}
+
 func (m Model) Done() bool {
 	return m.done
 }
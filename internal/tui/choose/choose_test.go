@@ -0,0 +1,37 @@
+package choose
+
+import (
+	"reflect"
+	"testing"
+)
+
+func helpKeys(m Model) []string {
+	keys := []string{}
+	for _, b := range m.Bindings() {
+		if b.Enabled() {
+			keys = append(keys, b.Help().Key)
+		}
+	}
+
+	return keys
+}
+
+func TestBindings(t *testing.T) {
+	tests := []struct {
+		name  string
+		model Model
+		want  []string
+	}{
+		{name: "plain", model: New([]string{"a", "b"}), want: []string{"↑/k", "↓/j", "enter"}},
+		{name: "filterable", model: New([]string{"a", "b"}).Filterable(), want: []string{"↑", "↓", "enter"}},
+		{name: "filterable multiple", model: New([]string{"a", "b"}).Filterable().Multiple(), want: []string{"↑", "↓", "space", "enter"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := helpKeys(tt.model); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
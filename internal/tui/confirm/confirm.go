@@ -1,6 +1,9 @@
 package confirm
 
 import (
+	"versioner/internal/tui/theme"
+
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -14,6 +17,8 @@ type Model struct {
 	confirmation bool
 
 	defaultSelection bool
+	noColor          bool
+	keys             theme.KeyMap
 
 	// styles
 	promptStyle     lipgloss.Style
@@ -22,14 +27,18 @@ type Model struct {
 }
 
 func New(prompt string) Model {
+	t := theme.Current()
+
 	m := Model{
 		affirmative:      "Yes",
 		negative:         "No",
 		confirmation:     false,
 		defaultSelection: false,
+		noColor:          t.NoColor,
+		keys:             theme.Keys(),
 		prompt:           prompt,
-		selectedStyle:    lipgloss.NewStyle().Background(lipgloss.Color("212")),
-		unselectedStyle:  lipgloss.NewStyle().Background(lipgloss.Color("235")),
+		selectedStyle:    lipgloss.NewStyle().Background(t.Accent),
+		unselectedStyle:  lipgloss.NewStyle().Background(t.Inactive),
 		promptStyle:      lipgloss.NewStyle().Margin(1, 0, 0, 0),
 	}
 
@@ -45,21 +54,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		return m, nil
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "n", "N":
+		switch {
+		case key.Matches(msg, m.keys.No):
 			m.confirmation = false
 			m.done = true
 			return m, nil
-		case "left", "h", "ctrl+p", "tab",
-			"right", "l", "ctrl+n", "shift+tab":
+		case key.Matches(msg, m.keys.Left, m.keys.Right, m.keys.Next):
 			if m.negative == "" {
 				break
 			}
 			m.confirmation = !m.confirmation
-		case "enter":
+		case key.Matches(msg, m.keys.Select):
 			m.done = true
 			return m, nil
-		case "y", "Y":
+		case key.Matches(msg, m.keys.Yes):
 			m.done = true
 			m.confirmation = true
 			return m, nil
@@ -75,7 +83,14 @@ func (m Model) View() string {
 
 	var aff, neg string
 
-	if m.confirmation {
+	if m.noColor {
+		aff, neg = " "+m.affirmative+" ", " "+m.negative+" "
+		if m.confirmation {
+			aff = "[" + m.affirmative + "]"
+		} else {
+			neg = "[" + m.negative + "]"
+		}
+	} else if m.confirmation {
 		aff = m.selectedStyle.Render(m.affirmative)
 		neg = m.unselectedStyle.Render(m.negative)
 	} else {
@@ -91,6 +106,22 @@ func (m Model) View() string {
 	return lipgloss.JoinVertical(lipgloss.Center, m.promptStyle.Render(m.prompt), lipgloss.JoinHorizontal(lipgloss.Left, aff, neg))
 }
 
+func (m Model) SetConfirmation(confirmation bool) Model {
+	m.confirmation = confirmation
+	return m
+}
+
+// Reset lets the question be answered again, keeping the last answer.
+func (m Model) Reset() Model {
+	m.done = false
+	return m
+}
+
+// Bindings are the keys shown in the help footer.
+func (m Model) Bindings() []key.Binding {
+	return []key.Binding{m.keys.Left, m.keys.Right, m.keys.Yes, m.keys.No, m.keys.Select}
+}
+
 func (m Model) Done() bool {
 	return m.done
 }
@@ -0,0 +1,267 @@
+package tui
+
+import (
+	"fmt"
+	"versioner/internal/changelog"
+	"versioner/internal/changeset"
+	"versioner/internal/config"
+	"versioner/internal/tui/choose"
+	"versioner/internal/tui/theme"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pkg/errors"
+)
+
+type initStep int
+
+const (
+	initBranch initStep = iota
+	initTag
+	initCommit
+	initChangelog
+	initTypes
+	initDone
+)
+
+const (
+	commitNone  = "Leave the release uncommitted"
+	commitNew   = "Commit the release"
+	commitAmend = "Amend the last commit with the release"
+)
+
+// InitOptions are the answers the init wizard offers for each question.
+type InitOptions struct {
+	Branches   []string
+	Templates  []string
+	Changelogs []string
+	// Types are offered as custom types, all of them selected up front.
+	Types []config.Type
+}
+
+type initModel struct {
+	step     initStep
+	steps    map[initStep]tea.Model
+	titles   map[initStep]string
+	types    []config.Type
+	result   config.Configuration
+	aborting bool
+	err      error
+	keys     theme.KeyMap
+
+	// styles
+	titleStyle  lipgloss.Style
+	footerStyle lipgloss.Style
+}
+
+// NewInitProgram asks for the config of a new project, the answers of conf
+// are selected up front.
+func NewInitProgram(conf config.Configuration, options InitOptions) (config.Configuration, bool, error) {
+	model := newInitModel(conf, options)
+
+	result, err := tea.NewProgram(model, tea.WithAltScreen()).Run()
+	if err != nil {
+		return conf, false, err
+	}
+
+	m, ok := result.(initModel)
+	if !ok {
+		return conf, false, errors.New("could not assert to init model")
+	}
+
+	return m.result, m.aborting, m.err
+}
+
+func newInitModel(conf config.Configuration, options InitOptions) initModel {
+	examples := make([]string, len(options.Templates))
+	for i, t := range options.Templates {
+		examples[i] = "like " + config.Configuration{TagTemplate: t}.TagName("1.2.3")
+	}
+
+	commit := commitNone
+	if conf.Commit {
+		commit = commitNew
+	}
+	if conf.Commit && conf.AmendCommit {
+		commit = commitAmend
+	}
+
+	tag := conf.TagTemplate
+	if len(tag) == 0 {
+		tag = config.VersionPlaceholder
+	}
+
+	m := initModel{
+		step: initBranch,
+		steps: map[initStep]tea.Model{
+			initBranch:    choose.New(options.Branches).Filterable().SetCursor(conf.BaseBranch),
+			initTag:       choose.New(options.Templates).SetDescriptions(examples).SetCursor(tag),
+			initCommit:    choose.New([]string{commitNone, commitNew, commitAmend}).SetCursor(commit),
+			initChangelog: choose.New(options.Changelogs).SetCursor(changelog.File(conf)),
+		},
+		titles: map[initStep]string{
+			initBranch:    "Which branch are changes merged into?",
+			initTag:       "How are version tags named?",
+			initCommit:    "What happens to the changes of a release?",
+			initChangelog: "Where is the changelog?",
+			initTypes:     "Which custom types of change should be added?",
+		},
+		types:       options.Types,
+		result:      conf,
+		keys:        theme.Keys(),
+		titleStyle:  lipgloss.NewStyle().Bold(true).MarginBottom(1),
+		footerStyle: lipgloss.NewStyle().MarginTop(1),
+	}
+
+	if len(options.Types) > 0 {
+		names := make([]string, len(options.Types))
+		descriptions := make([]string, len(options.Types))
+
+		for i, t := range options.Types {
+			names[i] = t.Type
+			descriptions[i] = fmt.Sprintf("%s · %s", t.Title, levelText(changeset.ConventionalType{Level: t.Level, CanBeBreaking: t.CanBeBreaking}))
+		}
+
+		m.steps[initTypes] = choose.New(names).SetDescriptions(descriptions).Multiple().SetSelected(names)
+	}
+
+	return m
+}
+
+func (m initModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m initModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			m.aborting = true
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Back):
+			return m.back(), nil
+		}
+	}
+
+	s, cmd := m.steps[m.step].Update(msg)
+
+	cm, ok := s.(choose.Model)
+	if !ok {
+		m.err = errors.New("could not assert Choose Model")
+		return m, tea.Quit
+	}
+
+	m.steps[m.step] = s
+
+	if !cm.Done() {
+		return m, cmd
+	}
+
+	switch m.step {
+	case initBranch:
+		m.result.BaseBranch = cm.Selected()
+	case initTag:
+		m.result.TagTemplate = cm.Selected()
+		if m.result.TagTemplate == config.VersionPlaceholder {
+			m.result.TagTemplate = ""
+		}
+	case initCommit:
+		m.result.Commit = cm.Selected() != commitNone
+		m.result.AmendCommit = cm.Selected() == commitAmend
+	case initChangelog:
+		m.result.Changelog = cm.Selected()
+		if m.result.Changelog == changelog.FileName {
+			m.result.Changelog = ""
+		}
+	case initTypes:
+		m.result.Types = m.selectedTypes(cm.Selections())
+	}
+
+	m = m.enter(m.step + 1)
+	if m.step == initDone {
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+// selectedTypes keeps the custom types already in the config that were not
+// offered, next to the offered types that were selected.
+func (m initModel) selectedTypes(selections []string) []config.Type {
+	offered := map[string]bool{}
+	for _, t := range m.types {
+		offered[t.Type] = true
+	}
+
+	types := []config.Type{}
+
+	for _, t := range m.result.Types {
+		if !offered[t.Type] {
+			types = append(types, t)
+		}
+	}
+
+	for _, t := range m.types {
+		for _, s := range selections {
+			if s == t.Type {
+				types = append(types, t)
+			}
+		}
+	}
+
+	return types
+}
+
+// enter moves to the step, skipping the types when there are none to offer.
+func (m initModel) enter(step initStep) initModel {
+	if step == initTypes && m.steps[initTypes] == nil {
+		step = initDone
+	}
+
+	if s, ok := m.steps[step].(choose.Model); ok {
+		m.steps[step] = s.Reset()
+	}
+
+	m.step = step
+
+	return m
+}
+
+func (m initModel) back() initModel {
+	if m.step == initBranch {
+		return m
+	}
+
+	return m.enter(m.step - 1)
+}
+
+func (m initModel) View() string {
+	if m.step == initDone {
+		return ""
+	}
+
+	current := m.steps[m.step]
+
+	keys := []key.Binding{}
+	if b, ok := current.(bindings); ok {
+		keys = b.Bindings()
+	}
+
+	if m.step != initBranch {
+		keys = append(keys, m.keys.Back)
+	}
+
+	progress := fmt.Sprintf("(%d/%d) ", m.step+1, m.stepCount())
+	hint := m.footerStyle.Render(theme.Help(append(keys, m.keys.Quit)...))
+
+	return lipgloss.JoinVertical(lipgloss.Left, m.titleStyle.Render(progress+m.titles[m.step]), current.View(), hint)
+}
+
+func (m initModel) stepCount() int {
+	if m.steps[initTypes] == nil {
+		return int(initTypes)
+	}
+
+	return int(initDone)
+}
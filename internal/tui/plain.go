@@ -0,0 +1,307 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"versioner/internal/changeset"
+	"versioner/internal/detect"
+
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
+)
+
+var ErrInputEnded = errors.New("input ended before the changeset was complete")
+
+// Plain reports if the prompts have to be asked line by line, as there is no
+// full terminal to draw them on.
+func Plain() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return true
+	}
+
+	return !isatty.IsTerminal(os.Stdin.Fd()) || !isatty.IsTerminal(os.Stdout.Fd())
+}
+
+type plainPrompt struct {
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+// NewPlainAddProgram asks the same questions as NewAddProgram one line at a
+// time, for terminals and screen readers that cannot use the full screen.
+func NewPlainAddProgram(in io.Reader, out io.Writer, packages []detect.Project, changed []string) (changeset.Changeset, error) {
+	c := changeset.Changeset{Type: changeset.Types[0].Type}
+
+	return newPlainPrompt(in, out).run(c, packages, changed)
+}
+
+// NewPlainEditProgram asks the same questions as NewPlainAddProgram with the
+// answers of an existing changeset as defaults.
+func NewPlainEditProgram(in io.Reader, out io.Writer, packages []detect.Project, c changeset.Changeset) (changeset.Changeset, error) {
+	selected := make([]string, len(c.Packages))
+	for i, p := range c.Packages {
+		selected[i] = p.Path
+	}
+
+	return newPlainPrompt(in, out).run(c, packages, selected)
+}
+
+func newPlainPrompt(in io.Reader, out io.Writer) plainPrompt {
+	return plainPrompt{in: bufio.NewScanner(in), out: out}
+}
+
+// run asks every question with the answers of c as defaults, selected are the
+// packages selected up front.
+func (p plainPrompt) run(c changeset.Changeset, packages []detect.Project, selected []string) (changeset.Changeset, error) {
+	var err error
+
+	if c.Type, err = p.askType(c.Type); err != nil {
+		return c, err
+	}
+
+	c.Breaking = false
+	if changeset.Types.CanBeBreaking(c.Type) {
+		if c.Breaking, err = p.askBreaking(c.Breaking); err != nil {
+			return c, err
+		}
+	}
+
+	if len(packages) > 1 {
+		if c.Packages, err = p.askPackages(c, packages, selected); err != nil {
+			return c, err
+		}
+	}
+
+	if c.Summary, err = p.askSummary(c.Summary); err != nil {
+		return c, err
+	}
+
+	if c.Body, err = p.askBody(c.Body); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (p plainPrompt) askType(current string) (string, error) {
+	def := 1
+
+	fmt.Fprintln(p.out, "Type of change:")
+	for i, t := range changeset.Types {
+		fmt.Fprintf(p.out, "  %d) %-10s %s · %s\n", i+1, t.Type, t.Title, levelText(t))
+
+		if t.Type == current {
+			def = i + 1
+		}
+	}
+
+	for {
+		answer, err := p.ask(fmt.Sprintf("Choose a type [1-%d] (default %d): ", len(changeset.Types), def))
+		if err != nil {
+			return "", err
+		}
+
+		if len(answer) == 0 {
+			return changeset.Types[def-1].Type, nil
+		}
+
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(changeset.Types) {
+			return changeset.Types[n-1].Type, nil
+		}
+
+		for _, t := range changeset.Types {
+			if t.Type == answer {
+				return t.Type, nil
+			}
+		}
+
+		fmt.Fprintln(p.out, "Not a valid type, answer with its number or name.")
+	}
+}
+
+func (p plainPrompt) askBreaking(current bool) (bool, error) {
+	return p.askYesNo("Are your change/changes breaking?", current)
+}
+
+func (p plainPrompt) askYesNo(question string, current bool) (bool, error) {
+	question += " [y/N]: "
+	if current {
+		question = strings.Replace(question, "[y/N]", "[Y/n]", 1)
+	}
+
+	for {
+		answer, err := p.ask(question)
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(answer) {
+		case "":
+			return current, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+
+		fmt.Fprintln(p.out, "Answer with y or n.")
+	}
+}
+
+// askPackages asks which packages are affected and the bump of each, like the
+// full screen prompt the first package is the default when none is selected.
+func (p plainPrompt) askPackages(c changeset.Changeset, packages []detect.Project, selected []string) ([]changeset.Package, error) {
+	defaults := []string{}
+
+	fmt.Fprintln(p.out, "Packages:")
+	for i, pkg := range packages {
+		fmt.Fprintf(p.out, "  %d) %s  %s\n", i+1, pkg.Path, pkg.Name)
+
+		for _, s := range selected {
+			if s == pkg.Path {
+				defaults = append(defaults, strconv.Itoa(i+1))
+			}
+		}
+	}
+
+	if len(defaults) == 0 {
+		defaults = []string{"1"}
+	}
+
+	var paths []string
+
+	for len(paths) == 0 {
+		answer, err := p.ask(fmt.Sprintf("Packages affected, comma separated numbers (default %s): ", strings.Join(defaults, ",")))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(answer) == 0 {
+			answer = strings.Join(defaults, ",")
+		}
+
+		paths = []string{}
+
+		for _, a := range strings.Split(answer, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(a))
+			if err != nil || n < 1 || n > len(packages) {
+				fmt.Fprintf(p.out, "%q is not one of the packages.\n", strings.TrimSpace(a))
+				paths = nil
+				break
+			}
+
+			paths = append(paths, packages[n-1].Path)
+		}
+	}
+
+	pp := selectedPackages(c, paths)
+
+	for i, pkg := range pp {
+		level, err := p.askLevel(pkg)
+		if err != nil {
+			return nil, err
+		}
+
+		pp[i].Level = level
+	}
+
+	return pp, nil
+}
+
+func (p plainPrompt) askLevel(pkg changeset.Package) (string, error) {
+	def := pkg.Level
+	if len(def) == 0 {
+		def = "none"
+	}
+
+	for {
+		answer, err := p.ask(fmt.Sprintf("Bump of %s [major/minor/patch/none] (default %s): ", pkg.Path, def))
+		if err != nil {
+			return "", err
+		}
+
+		if len(answer) == 0 {
+			return pkg.Level, nil
+		}
+
+		if level, ok := changeset.ParseLevel(answer); ok {
+			return level, nil
+		}
+
+		fmt.Fprintln(p.out, "Answer with major, minor, patch or none.")
+	}
+}
+
+func (p plainPrompt) askSummary(current string) (string, error) {
+	question := "Summary of this change: "
+	if len(current) > 0 {
+		question = fmt.Sprintf("Summary of this change (default %q): ", current)
+	}
+
+	for {
+		answer, err := p.ask(question)
+		if err != nil {
+			return "", err
+		}
+
+		if len(answer) == 0 {
+			answer = current
+		}
+
+		if len(answer) > 0 {
+			return answer, nil
+		}
+
+		fmt.Fprintln(p.out, "summary cannot be empty")
+	}
+}
+
+// askBody reads the details of the change until an empty line, an empty first
+// line keeps the current details.
+func (p plainPrompt) askBody(current string) (string, error) {
+	if len(current) > 0 {
+		fmt.Fprintln(p.out, "Details of this change, end with an empty line (empty keeps the current details):")
+	} else {
+		fmt.Fprintln(p.out, "Details of this change, end with an empty line (optional):")
+	}
+
+	lines := []string{}
+
+	for p.in.Scan() {
+		l := strings.TrimRight(p.in.Text(), "\r")
+		if len(strings.TrimSpace(l)) == 0 {
+			break
+		}
+
+		lines = append(lines, l)
+	}
+
+	if err := p.in.Err(); err != nil {
+		return "", err
+	}
+
+	if len(lines) == 0 {
+		return current, nil
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+func (p plainPrompt) ask(question string) (string, error) {
+	fmt.Fprint(p.out, question)
+
+	if !p.in.Scan() {
+		if err := p.in.Err(); err != nil {
+			return "", err
+		}
+
+		fmt.Fprintln(p.out)
+		return "", ErrInputEnded
+	}
+
+	return strings.TrimSpace(p.in.Text()), nil
+}
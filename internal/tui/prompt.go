@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"os"
+	"versioner/internal/tui/confirm"
+	"versioner/internal/tui/theme"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+)
+
+type confirmModel struct {
+	confirm  confirm.Model
+	aborting bool
+}
+
+// Confirm asks a yes or no question inline, aborting counts as a no.
+func Confirm(prompt string) (bool, error) {
+	if Plain() {
+		ok, err := newPlainPrompt(os.Stdin, os.Stdout).askYesNo(prompt, false)
+		if errors.Is(err, ErrInputEnded) {
+			return false, nil
+		}
+
+		return ok, err
+	}
+
+	p := tea.NewProgram(confirmModel{confirm: confirm.New(prompt)})
+
+	result, err := p.Run()
+	if err != nil {
+		return false, err
+	}
+
+	m, ok := result.(confirmModel)
+	if !ok {
+		return false, errors.New("could not assert to confirm model")
+	}
+
+	return !m.aborting && m.confirm.Confirmation(), nil
+}
+
+func (m confirmModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m confirmModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, theme.Keys().Quit) {
+		m.aborting = true
+		return m, tea.Quit
+	}
+
+	c, cmd := m.confirm.Update(msg)
+
+	cm, ok := c.(confirm.Model)
+	if !ok {
+		return m, tea.Quit
+	}
+
+	m.confirm = cm
+
+	if cm.Done() {
+		return m, tea.Quit
+	}
+
+	return m, cmd
+}
+
+func (m confirmModel) View() string {
+	return m.confirm.View()
+}
@@ -0,0 +1,371 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"versioner/internal/changelog"
+	"versioner/internal/changeset"
+	"versioner/internal/editor"
+	"versioner/internal/tui/choose"
+	"versioner/internal/tui/confirm"
+	"versioner/internal/tui/theme"
+
+	"github.com/Masterminds/semver"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pkg/errors"
+)
+
+type reviewMode int
+
+const (
+	browsing reviewMode = iota
+	retyping
+	deleting
+	releasing
+)
+
+const listWidth = 36
+
+// editedMsg is sent when the editor opened on a changeset exits.
+type editedMsg struct {
+	err error
+}
+
+type reviewModel struct {
+	wd      string
+	latest  semver.Version
+	cc      changeset.Changesets
+	index   int
+	mode    reviewMode
+	chooser tea.Model
+	confirm tea.Model
+	status  string
+	failed  bool
+	release bool
+	keys    theme.KeyMap
+
+	// styles
+	paneStyle    lipgloss.Style
+	cursorStyle  lipgloss.Style
+	subduedStyle lipgloss.Style
+	errorStyle   lipgloss.Style
+	titleStyle   lipgloss.Style
+}
+
+// NewReviewProgram browses the pending changesets, every change made to them
+// is saved right away. It reports if a release was asked for when leaving.
+func NewReviewProgram(wd string, cc changeset.Changesets, latest semver.Version) (bool, error) {
+	t := theme.Current()
+
+	model := reviewModel{
+		wd:           wd,
+		latest:       latest,
+		cc:           append(changeset.Changesets{}, cc...),
+		keys:         theme.Keys(),
+		paneStyle:    lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(t.Subdued).Padding(0, 1),
+		cursorStyle:  lipgloss.NewStyle().Foreground(t.Accent),
+		subduedStyle: lipgloss.NewStyle().Foreground(t.Subdued),
+		errorStyle:   lipgloss.NewStyle().Foreground(t.Error),
+		titleStyle:   lipgloss.NewStyle().Bold(true),
+	}
+
+	result, err := tea.NewProgram(model, tea.WithAltScreen()).Run()
+	if err != nil {
+		return false, err
+	}
+
+	m, ok := result.(reviewModel)
+	if !ok {
+		return false, errors.New("could not assert to review model")
+	}
+
+	return m.release, nil
+}
+
+func (m reviewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case editedMsg:
+		return m.reload(msg.err), nil
+	case tea.KeyMsg:
+		if key.Matches(msg, m.keys.Quit) {
+			return m, tea.Quit
+		}
+
+		if m.mode != browsing && key.Matches(msg, m.keys.Back) {
+			m.mode = browsing
+			return m, nil
+		}
+	}
+
+	switch m.mode {
+	case retyping:
+		return m.updateRetype(msg)
+	case deleting, releasing:
+		return m.updateConfirm(msg)
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	m.status, m.failed = "", false
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Back):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Release):
+		if len(m.cc) == 0 {
+			m.status = "There are no changesets to release"
+			return m, nil
+		}
+
+		entry, err := changelog.NewEntry(m.latest, m.cc)
+		if err != nil {
+			return m.fail(err), nil
+		}
+
+		m.mode = releasing
+		m.confirm = confirm.New(fmt.Sprintf("Release %s now?", entry.Version))
+
+		return m, nil
+	}
+
+	if len(m.cc) == 0 {
+		return m, nil
+	}
+
+	c := m.cc[m.index]
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Down):
+		m.index = (m.index + 1) % len(m.cc)
+	case key.Matches(keyMsg, m.keys.Up):
+		m.index = (m.index - 1 + len(m.cc)) % len(m.cc)
+	case key.Matches(keyMsg, m.keys.Retype):
+		m.mode = retyping
+		m.chooser = typeChooser().SetCursor(c.Type)
+	case key.Matches(keyMsg, m.keys.Breaking):
+		if !changeset.Types.CanBeBreaking(c.Type) {
+			m.status = fmt.Sprintf("%s cannot be breaking", c.Type)
+			return m, nil
+		}
+
+		c.Breaking = !c.Breaking
+		return m.save(c), nil
+	case key.Matches(keyMsg, m.keys.Edit):
+		return m, tea.ExecProcess(editor.Cmd(c.Path()), func(err error) tea.Msg {
+			return editedMsg{err: err}
+		})
+	case key.Matches(keyMsg, m.keys.Delete):
+		m.mode = deleting
+		m.confirm = confirm.New(fmt.Sprintf("Delete %s?", c.Name()))
+	}
+
+	return m, nil
+}
+
+func (m reviewModel) updateRetype(msg tea.Msg) (tea.Model, tea.Cmd) {
+	ch, cmd := m.chooser.Update(msg)
+	m.chooser = ch
+
+	cm, ok := ch.(choose.Model)
+	if !ok || !cm.Done() {
+		return m, cmd
+	}
+
+	c := m.cc[m.index]
+	c.Type = cm.Selected()
+
+	if !changeset.Types.CanBeBreaking(c.Type) {
+		c.Breaking = false
+	}
+
+	m.mode = browsing
+
+	return m.save(c), nil
+}
+
+func (m reviewModel) updateConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	co, cmd := m.confirm.Update(msg)
+	m.confirm = co
+
+	cm, ok := co.(confirm.Model)
+	if !ok || !cm.Done() {
+		return m, cmd
+	}
+
+	mode := m.mode
+	m.mode = browsing
+
+	if !cm.Confirmation() {
+		return m, nil
+	}
+
+	if mode == releasing {
+		m.release = true
+		return m, tea.Quit
+	}
+
+	c := m.cc[m.index]
+	if err := c.Remove(); err != nil {
+		return m.fail(err), nil
+	}
+
+	m.cc = append(append(changeset.Changesets{}, m.cc[:m.index]...), m.cc[m.index+1:]...)
+	m.index = clamp(m.index, 0, len(m.cc)-1)
+	m.status = fmt.Sprintf("Deleted %s", c.Name())
+
+	return m, nil
+}
+
+// save writes the changeset back to its own file.
+func (m reviewModel) save(c changeset.Changeset) reviewModel {
+	if err := c.SaveAs(m.wd, c.Name()); err != nil {
+		return m.fail(err)
+	}
+
+	cc := append(changeset.Changesets{}, m.cc...)
+	cc[m.index] = c
+	m.cc = cc
+	m.status = fmt.Sprintf("Saved %s", c.Name())
+
+	return m
+}
+
+// reload reads the changeset again after it was changed in the editor.
+func (m reviewModel) reload(err error) reviewModel {
+	if err != nil {
+		return m.fail(errors.Wrap(err, "could not run editor"))
+	}
+
+	c := m.cc[m.index]
+
+	b, err := os.ReadFile(c.Path())
+	if err != nil {
+		return m.fail(err)
+	}
+
+	edited, err := changeset.Parse(string(b), c.Path())
+	if err != nil {
+		return m.fail(err)
+	}
+
+	cc := append(changeset.Changesets{}, m.cc...)
+	cc[m.index] = edited
+	m.cc = cc
+	m.status = fmt.Sprintf("Saved %s", c.Name())
+
+	return m
+}
+
+func (m reviewModel) fail(err error) reviewModel {
+	m.status, m.failed = err.Error(), true
+	return m
+}
+
+func (m reviewModel) View() string {
+	panes := lipgloss.JoinHorizontal(lipgloss.Top, m.listView(), " ", m.detailView())
+
+	status := m.subduedStyle.Render(m.status)
+	if m.failed {
+		status = m.errorStyle.Render(m.status)
+	}
+
+	keys := []key.Binding{m.keys.Up, m.keys.Down, m.keys.Retype, m.keys.Breaking, m.keys.Edit, m.keys.Delete, m.keys.Release, m.keys.Quit}
+
+	switch m.mode {
+	case retyping:
+		keys = append(m.chooser.(bindings).Bindings(), m.keys.Back)
+	case deleting, releasing:
+		keys = append(m.confirm.(bindings).Bindings(), m.keys.Back)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.titleStyle.Render(fmt.Sprintf("Pending changesets (%d)", len(m.cc))),
+		panes,
+		m.previewView(),
+		status,
+		theme.Help(keys...),
+	)
+}
+
+func (m reviewModel) listView() string {
+	if len(m.cc) == 0 {
+		return m.paneStyle.Width(listWidth).Render(m.subduedStyle.Render("No pending changesets"))
+	}
+
+	lines := make([]string, len(m.cc))
+
+	for i, c := range m.cc {
+		t := c.Type
+		if c.Breaking {
+			t += "!"
+		}
+
+		line := fmt.Sprintf("%-9s %s", t, c.Name())
+		if len(line) > listWidth-2 {
+			line = line[:listWidth-3] + "…"
+		}
+
+		if i == m.index {
+			lines[i] = m.cursorStyle.Render(theme.Current().Cursor + " " + line)
+		} else {
+			lines[i] = strings.Repeat(" ", lipgloss.Width(theme.Current().Cursor)+1) + line
+		}
+	}
+
+	return m.paneStyle.Width(listWidth).Render(strings.Join(lines, "\n"))
+}
+
+func (m reviewModel) detailView() string {
+	switch m.mode {
+	case retyping:
+		return m.paneStyle.Render(m.chooser.View())
+	case deleting, releasing:
+		return m.paneStyle.Render(m.confirm.View())
+	}
+
+	if len(m.cc) == 0 {
+		return ""
+	}
+
+	return m.paneStyle.Render(strings.TrimSpace(m.cc[m.index].Markdown()))
+}
+
+// previewView shows the version and the changelog entry a release of the
+// changesets would create right now.
+func (m reviewModel) previewView() string {
+	if len(m.cc) == 0 {
+		return m.subduedStyle.Render("Nothing to release")
+	}
+
+	entry, err := changelog.NewEntry(m.latest, m.cc)
+	if err != nil {
+		return m.errorStyle.Render(err.Error())
+	}
+
+	level, _ := m.cc.HighestLevel()
+	header := m.titleStyle.Render(fmt.Sprintf("Next version %s → %s (%s)", m.latest.String(), entry.Version, level))
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, m.paneStyle.Render(strings.TrimSpace(entry.Markdown())))
+}
+
+func clamp(x, min, max int) int {
+	if x > max {
+		x = max
+	}
+
+	if x < min {
+		return min
+	}
+
+	return x
+}
@@ -0,0 +1,143 @@
+package theme
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pkg/errors"
+)
+
+type KeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Left   key.Binding
+	Right  key.Binding
+	First  key.Binding
+	Last   key.Binding
+	Next   key.Binding
+	Select key.Binding
+	Toggle key.Binding
+	Yes    key.Binding
+	No     key.Binding
+	Submit key.Binding
+	Back   key.Binding
+	Editor key.Binding
+	Quit   key.Binding
+
+	// review
+	Retype   key.Binding
+	Breaking key.Binding
+	Edit     key.Binding
+	Delete   key.Binding
+	Release  key.Binding
+}
+
+var currentKeys = DefaultKeyMap()
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up:     key.NewBinding(key.WithKeys("up", "k", "ctrl+k", "ctrl+p"), key.WithHelp("↑/k", "up")),
+		Down:   key.NewBinding(key.WithKeys("down", "j", "ctrl+j", "ctrl+n"), key.WithHelp("↓/j", "down")),
+		Left:   key.NewBinding(key.WithKeys("left", "h", "ctrl+b"), key.WithHelp("←/h", "left")),
+		Right:  key.NewBinding(key.WithKeys("right", "l", "ctrl+f"), key.WithHelp("→/l", "right")),
+		First:  key.NewBinding(key.WithKeys("g", "home"), key.WithHelp("g", "first")),
+		Last:   key.NewBinding(key.WithKeys("G", "end"), key.WithHelp("G", "last")),
+		Next:   key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "switch")),
+		Select: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "select")),
+		Toggle: key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "toggle")),
+		Yes:    key.NewBinding(key.WithKeys("y", "Y"), key.WithHelp("y", "yes")),
+		No:     key.NewBinding(key.WithKeys("n", "N"), key.WithHelp("n", "no")),
+		Submit: key.NewBinding(key.WithKeys("ctrl+d", "ctrl+s"), key.WithHelp("ctrl+d", "save")),
+		Back:   key.NewBinding(key.WithKeys("esc", "shift+tab"), key.WithHelp("esc", "back")),
+		Editor: key.NewBinding(key.WithKeys("ctrl+e"), key.WithHelp("ctrl+e", "continue in $EDITOR")),
+		Quit:   key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+
+		Retype:   key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "type")),
+		Breaking: key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "breaking")),
+		Edit:     key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit")),
+		Delete:   key.NewBinding(key.WithKeys("d", "delete"), key.WithHelp("d", "delete")),
+		Release:  key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "release now")),
+	}
+}
+
+// NewKeyMap replaces the keys of the default bindings named in the config,
+// like "submit": ["ctrl+s"].
+func NewKeyMap(conf map[string][]string) (KeyMap, error) {
+	km := DefaultKeyMap()
+
+	bindings := map[string]*key.Binding{
+		"up":     &km.Up,
+		"down":   &km.Down,
+		"left":   &km.Left,
+		"right":  &km.Right,
+		"first":  &km.First,
+		"last":   &km.Last,
+		"next":   &km.Next,
+		"select": &km.Select,
+		"toggle": &km.Toggle,
+		"yes":    &km.Yes,
+		"no":     &km.No,
+		"submit": &km.Submit,
+		"back":   &km.Back,
+		"editor": &km.Editor,
+		"quit":   &km.Quit,
+
+		"retype":   &km.Retype,
+		"breaking": &km.Breaking,
+		"edit":     &km.Edit,
+		"delete":   &km.Delete,
+		"release":  &km.Release,
+	}
+
+	for name, keys := range conf {
+		b, ok := bindings[name]
+		if !ok {
+			names := make([]string, 0, len(bindings))
+			for n := range bindings {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+
+			return km, errors.Errorf("unknown key binding %q, use one of %s", name, strings.Join(names, ", "))
+		}
+
+		if len(keys) == 0 {
+			return km, errors.Errorf("key binding %q needs at least one key", name)
+		}
+
+		keys = append([]string{}, keys...)
+		for i, k := range keys {
+			if k == "space" {
+				keys[i] = " "
+			}
+		}
+
+		help := keys[0]
+		if help == " " {
+			help = "space"
+		}
+
+		b.SetKeys(keys...)
+		b.SetHelp(help, b.Help().Desc)
+	}
+
+	return km, nil
+}
+
+// Keys are the key bindings the components are created with.
+func Keys() KeyMap {
+	return currentKeys
+}
+
+// Help renders the bindings as a single line footer.
+func Help(bindings ...key.Binding) string {
+	h := help.New()
+	h.Styles.ShortKey = lipgloss.NewStyle().Foreground(current.Subdued).Bold(true)
+	h.Styles.ShortDesc = lipgloss.NewStyle().Foreground(current.Subdued)
+	h.Styles.ShortSeparator = lipgloss.NewStyle().Foreground(current.Subdued)
+
+	return h.ShortHelpView(bindings)
+}
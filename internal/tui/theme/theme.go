@@ -0,0 +1,98 @@
+package theme
+
+import (
+	"os"
+	"versioner/internal/config"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Theme struct {
+	// Accent marks the cursor, the selection and matches.
+	Accent lipgloss.TerminalColor
+	// Subdued is used for headers, hints and placeholders.
+	Subdued lipgloss.TerminalColor
+	// Inactive is the background of options that are not chosen.
+	Inactive lipgloss.TerminalColor
+	Error    lipgloss.TerminalColor
+	Text     lipgloss.TerminalColor
+
+	Prompt           string
+	Cursor           string
+	SelectedPrefix   string
+	UnselectedPrefix string
+
+	// NoColor is set with NO_COLOR, components that only use color to tell
+	// options apart have to mark them in another way.
+	NoColor bool
+}
+
+var current = New(config.Theme{})
+
+func Default() Theme {
+	return Theme{
+		Accent:           lipgloss.Color("212"),
+		Subdued:          lipgloss.Color("240"),
+		Inactive:         lipgloss.Color("235"),
+		Error:            lipgloss.Color("9"),
+		Text:             lipgloss.Color("7"),
+		Prompt:           "┃ ",
+		Cursor:           ">",
+		SelectedPrefix:   "◉ ",
+		UnselectedPrefix: "○ ",
+		NoColor:          len(os.Getenv("NO_COLOR")) > 0,
+	}
+}
+
+// New overrides the default theme with the values set in the config.
+func New(conf config.Theme) Theme {
+	t := Default()
+
+	for c, v := range map[*lipgloss.TerminalColor]string{
+		&t.Accent:   conf.Accent,
+		&t.Subdued:  conf.Subdued,
+		&t.Inactive: conf.Inactive,
+		&t.Error:    conf.Error,
+		&t.Text:     conf.Text,
+	} {
+		if len(v) > 0 {
+			*c = lipgloss.Color(v)
+		}
+	}
+
+	for s, v := range map[*string]string{
+		&t.Prompt:           conf.Prompt,
+		&t.Cursor:           conf.Cursor,
+		&t.SelectedPrefix:   conf.SelectedPrefix,
+		&t.UnselectedPrefix: conf.UnselectedPrefix,
+	} {
+		if len(v) > 0 {
+			*s = v
+		}
+	}
+
+	return t
+}
+
+// Current is the theme the components are created with.
+func Current() Theme {
+	return current
+}
+
+// Configure sets the theme and the key bindings from the config, it has to
+// be called before any component is created.
+func Configure(conf config.Configuration) error {
+	keys, err := NewKeyMap(conf.Keys)
+	if err != nil {
+		return err
+	}
+
+	current = Default()
+	if conf.Theme != nil {
+		current = New(*conf.Theme)
+	}
+
+	currentKeys = keys
+
+	return nil
+}
@@ -1,6 +1,13 @@
 package write
 
 import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+	"versioner/internal/changeset"
+	"versioner/internal/tui/theme"
+
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -11,31 +18,49 @@ type Model struct {
 	header      string
 	headerStyle lipgloss.Style
 	done        bool
+	limit       int
 	textarea    textarea.Model
+	keys        theme.KeyMap
+
+	// styles
+	counterStyle lipgloss.Style
+	overStyle    lipgloss.Style
+	previewStyle lipgloss.Style
+	titleStyle   lipgloss.Style
+	summaryStyle lipgloss.Style
 }
 
 func New(placeholder string) Model {
-	m := Model{}
+	t := theme.Current()
+
+	m := Model{
+		keys:         theme.Keys(),
+		counterStyle: lipgloss.NewStyle().Foreground(t.Subdued),
+		overStyle:    lipgloss.NewStyle().Foreground(t.Error),
+		previewStyle: lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(t.Subdued).Padding(0, 1).MarginLeft(2).Width(40),
+		titleStyle:   lipgloss.NewStyle().Foreground(t.Subdued),
+		summaryStyle: lipgloss.NewStyle().Bold(true),
+	}
 
 	a := textarea.New()
 	a.Focus()
 
-	a.Prompt = "┃ "
+	a.Prompt = t.Prompt
 	a.Placeholder = placeholder
 	a.ShowLineNumbers = false
 	a.CharLimit = 0
 
 	style := textarea.Style{
-		Placeholder:      lipgloss.NewStyle().Foreground(lipgloss.Color("240")),
-		CursorLineNumber: lipgloss.NewStyle().Foreground(lipgloss.Color("7")),
+		Placeholder:      lipgloss.NewStyle().Foreground(t.Subdued),
+		CursorLineNumber: lipgloss.NewStyle().Foreground(t.Text),
 		EndOfBuffer:      lipgloss.NewStyle().Foreground(lipgloss.Color("0")),
-		LineNumber:       lipgloss.NewStyle().Foreground(lipgloss.Color("7")),
-		Prompt:           lipgloss.NewStyle().Foreground(lipgloss.Color("7")),
+		LineNumber:       lipgloss.NewStyle().Foreground(t.Text),
+		Prompt:           lipgloss.NewStyle().Foreground(t.Text),
 	}
 
 	a.BlurredStyle = style
 	a.FocusedStyle = style
-	a.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+	a.Cursor.Style = lipgloss.NewStyle().Foreground(t.Accent)
 
 	a.SetWidth(50)
 	a.SetHeight(5)
@@ -52,12 +77,59 @@ func (m Model) View() string {
 		return ""
 	}
 
+	editor := lipgloss.JoinVertical(lipgloss.Left, m.textarea.View(), m.counterView())
+	view := lipgloss.JoinHorizontal(lipgloss.Top, editor, m.previewView())
+
 	if m.header != "" {
 		header := m.headerStyle.Render(m.header)
-		return lipgloss.JoinVertical(lipgloss.Left, header, m.textarea.View())
+		return lipgloss.JoinVertical(lipgloss.Left, header, view)
+	}
+
+	return view
+}
+
+// counterView counts the characters of the summary, the limit is soft so
+// going over it is only shown and never stops the summary from being saved.
+func (m Model) counterView() string {
+	summary, _ := changeset.SplitSummary(m.Value())
+	count := utf8.RuneCountInString(summary)
+
+	if m.limit <= 0 {
+		return m.counterStyle.Render(fmt.Sprintf("%d characters", count))
 	}
 
-	return m.textarea.View()
+	counter := fmt.Sprintf("%d/%d", count, m.limit)
+	if count > m.limit {
+		counter = m.overStyle.Render(counter + " summary is over the limit")
+	}
+
+	return m.counterStyle.Render(counter)
+}
+
+// previewView shows the summary the way it ends up in the changelog and the
+// body that is only kept in the changeset.
+func (m Model) previewView() string {
+	summary, body := changeset.SplitSummary(m.Value())
+
+	lines := []string{m.titleStyle.Render("Changelog"), m.summaryStyle.Render(summary), "", m.titleStyle.Render("Body")}
+
+	if len(body) == 0 {
+		lines = append(lines, m.titleStyle.Render("No body, only the summary is saved"))
+		return m.previewStyle.Render(strings.Join(lines, "\n"))
+	}
+
+	for _, l := range strings.Split(body, "\n") {
+		switch {
+		case strings.HasPrefix(l, "#"):
+			l = m.summaryStyle.Render(strings.TrimSpace(strings.TrimLeft(l, "#")))
+		case strings.HasPrefix(l, "- "), strings.HasPrefix(l, "* "):
+			l = "• " + l[2:]
+		}
+
+		lines = append(lines, l)
+	}
+
+	return m.previewStyle.Render(strings.Join(lines, "\n"))
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -67,8 +139,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.textarea.SetWidth(msg.Width)
 		}
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "enter":
+		if key.Matches(msg, m.keys.Submit) {
 			m.done = true
 			return m, nil
 		}
@@ -83,6 +154,29 @@ func (m Model) Value() string {
 	return m.textarea.Value()
 }
 
+func (m Model) SetValue(value string) Model {
+	m.textarea.SetValue(value)
+	return m
+}
+
+// SetLimit sets the soft limit of characters for the summary, zero means
+// there is no limit.
+func (m Model) SetLimit(limit int) Model {
+	m.limit = limit
+	return m
+}
+
+// Reset lets the text be submitted again, keeping what was written.
+func (m Model) Reset() Model {
+	m.done = false
+	return m
+}
+
+// Bindings are the keys shown in the help footer.
+func (m Model) Bindings() []key.Binding {
+	return []key.Binding{m.keys.Submit}
+}
+
 func (m Model) Done() bool {
 	return m.done
 }
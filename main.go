@@ -1,38 +1,99 @@
 package main
 
 import (
-	"fmt"
 	"os"
+	"path/filepath"
+	"versioner/internal/changeset"
 	"versioner/internal/command"
+	"versioner/internal/config"
 	"versioner/internal/context"
+	"versioner/internal/tui/theme"
 
 	"github.com/alecthomas/kong"
 	"github.com/go-git/go-git/v5"
 )
 
+//go:generate sh -c "go run . config schema > config.schema.json"
+
 var cmd struct {
-	Init    command.Init    `cmd:"" help:"Initialize setup of project."`
-	Add     command.Add     `cmd:"" help:"Add changelog to your project"`
-	Version command.Version `cmd:"" help:"Creates a new version based on existing changesets"`
-	Tag     command.Tag     `cmd:"" help:"Creates a new tag of the current version"`
+	Init      command.Init      `cmd:"" help:"Initialize setup of project."`
+	Add       command.Add       `cmd:"" help:"Add changelog to your project"`
+	Changeset command.Changeset `cmd:"" help:"Manage pending changesets"`
+	Review    command.Review    `cmd:"" help:"Browse and edit pending changesets before releasing them"`
+	Version   command.Version   `cmd:"" help:"Creates a new version based on existing changesets"`
+	Tag       command.Tag       `cmd:"" help:"Creates a new tag of the current version"`
+	Changelog command.Changelog `cmd:"" help:"Manage the changelog"`
+	Check     command.Check     `cmd:"" help:"Checks for continuous integration"`
+	Lint      command.Lint      `cmd:"" help:"Lints changesets, config and changelog"`
+	Config    command.Config    `cmd:"" help:"Inspect, change and migrate the config"`
+
+	Set map[string]string `short:"c" placeholder:"KEY=VALUE" help:"Overrides a config value for this run, like -c commit=true"`
+	Dir string            `short:"C" type:"existingdir" placeholder:"PATH" help:"Runs in the project at PATH instead of the working directory"`
 }
 
 func main() {
-	wd, err := os.Getwd()
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
+	cli := kong.Parse(&cmd)
 
-	repo, err := git.PlainOpen(wd)
-	if err != nil {
-		fmt.Println(err)
-		return
+	ctx, err := open(cmd.Dir)
+	cli.FatalIfErrorf(err)
+
+	cli.FatalIfErrorf(config.Override(cmd.Set))
+
+	// Custom types are needed by every command reading changesets and the
+	// theme by every prompt, a missing or broken config is left for the
+	// command itself to report.
+	if conf, err := config.Read(ctx.Wd()); err == nil {
+		cli.FatalIfErrorf(changeset.Register(conf.Types))
+		cli.FatalIfErrorf(theme.Configure(conf))
 	}
 
-	ctx := context.New(repo, wd)
-	cli := kong.Parse(&cmd)
 	// Call the Run() method of the selected parsed command.
 	err = cli.Run(&ctx)
 	cli.FatalIfErrorf(err)
 }
+
+// open finds the repository from dir or the working directory. The project
+// is dir when given, otherwise the closest directory with a config up to the
+// root of the worktree and the root itself without one, which is where init
+// puts the config.
+func open(dir string) (context.Context, error) {
+	start := dir
+	if len(start) == 0 {
+		wd, err := os.Getwd()
+		if err != nil {
+			return context.Context{}, err
+		}
+
+		start = wd
+	}
+
+	start, err := filepath.Abs(start)
+	if err != nil {
+		return context.Context{}, err
+	}
+
+	repo, err := git.PlainOpenWithOptions(start, &git.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+	if err != nil {
+		return context.Context{}, err
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return context.Context{}, err
+	}
+
+	root := w.Filesystem.Root()
+
+	wd := start
+	if len(dir) == 0 {
+		var ok bool
+		if wd, ok = config.FindProject(start, root); !ok {
+			wd = root
+		}
+	}
+
+	return context.New(repo, root, wd), nil
+}